@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printAgents(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	top, _ := cmd.Flags().GetInt("top")
+	noSignatures, _ := cmd.Flags().GetBool("no-signatures")
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	summaries, err := tools.SummarizeAgents(tasks, !noSignatures)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot summarize agents: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteAgents(os.Stdout, summaries, format, top); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	agentsCmd := &cobra.Command{
+		Use:   "agents",
+		Short: "Show task failures per agent",
+		Long: "Group tasks by the agent they ran on according to the Mesos state files in the bundle and show " +
+			"the number of tasks, failed and killed tasks, restarts, failure and restart rates, and the most " +
+			"common error signatures per agent. Agents with at least 2 failed tasks and a failure rate at least " +
+			"twice as high as the failure rate of the other agents are marked with \"<<\". " +
+			"Tasks which are not found in the Mesos state are grouped under the \"" + tools.UnknownAgent + "\" agent.",
+		Run: printAgents,
+	}
+	agentsCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	agentsCmd.Flags().IntP("top", "n", 3,
+		"number of error signatures to show per agent, 0 means all")
+	agentsCmd.Flags().Bool("no-signatures", false,
+		"don't read the task logs to find error signatures")
+	rootCmd.AddCommand(agentsCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func analyzeBundle(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	names, _ := cmd.Flags().GetStringArray("analyzer")
+	list, _ := cmd.Flags().GetBool("list")
+	minSeverity, _ := cmd.Flags().GetString("min-severity")
+	if list {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, a := range tools.Analyzers() {
+			_, _ = fmt.Fprintf(tw, "%v\t%v\n", a.Name(), a.Description())
+		}
+		_ = tw.Flush()
+		return
+	}
+	severity, err := tools.ParseSeverity(minSeverity)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	analyzers := tools.Analyzers()
+	if len(names) != 0 {
+		analyzers = analyzers[:0]
+		for _, name := range names {
+			a, err := tools.FindAnalyzer(name)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				exit(1)
+			}
+			analyzers = append(analyzers, a)
+		}
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	analysis := tools.RunAnalyzers(tools.Bundle{Path: bundlePath, Tasks: tasks}, analyzers).FilterSeverity(severity)
+	if err := tools.WriteAnalysis(os.Stdout, analysis, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	analyzeCmd := &cobra.Command{
+		Use:   "analyze",
+		Short: "Find known problems in the bundle",
+		Long: "Run the analyzers and print the issues they find ranked by severity: critical, warning and info. " +
+			"Every issue has the affected tasks, the evidence, e.g., log lines or plan step statuses, and " +
+			"a suggested remediation. Use --list to see the available analyzers.",
+		Run: analyzeBundle,
+	}
+	analyzeCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	analyzeCmd.Flags().StringArrayP("analyzer", "a", nil,
+		"run only this analyzer, can be repeated")
+	analyzeCmd.Flags().BoolP("list", "l", false,
+		"list the analyzers and exit")
+	analyzeCmd.Flags().StringP("min-severity", "s", tools.SeverityInfo,
+		"print only issues at least this severe: critical, warning or info")
+	rootCmd.AddCommand(analyzeCmd)
+}
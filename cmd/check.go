@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+// checkErrorExitCode is the exit code of the check command if the check cannot be done, it differs
+// from the exit code of failed expectations, so that pipelines can tell them apart.
+const checkErrorExitCode = 2
+
+func checkExpectations(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	expectations, err := tools.ReadExpectations(args[0])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(checkErrorExitCode)
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(checkErrorExitCode)
+	}
+	results := tools.CheckExpectations(tools.Bundle{Path: bundlePath, Tasks: tasks}, expectations)
+	var writer io.WriteCloser = os.Stdout
+	if output != "" {
+		if writer, err = os.Create(output); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot create output file: %v\n", err)
+			exit(checkErrorExitCode)
+		}
+	}
+	err = tools.WriteExpectationResults(writer, bundlePath, results, format)
+	if output != "" {
+		closeCloser(writer)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(checkErrorExitCode)
+	}
+	if !tools.ExpectationsPassed(results) {
+		exit(1)
+	}
+}
+
+func init() {
+	checkCmd := &cobra.Command{
+		Use:   "check <expectations file>",
+		Short: "Check the bundle against health expectations",
+		Long: "Check the bundle against the expectations from a JSON file and print whether every expectation " +
+			"passed. Expectation types:\n" +
+			"  running       every pod instance of the selected tasks has a running task\n" +
+			"  max-restarts  every selected task name is restarted at most max times\n" +
+			"  max-tasks     at most max tasks are selected, e.g., with the filter state=failed\n" +
+			"  max-issues    at most max issues of the analyzers, see the analyze command, are at least " +
+			"as severe as severity\n" +
+			"Tasks are selected with filter conditions like in the --filter flags of other commands. Example:\n" +
+			"  {\"expectations\": [\n" +
+			"    {\"name\": \"brokers are running\", \"type\": \"running\", \"filter\": [\"pod-type=kafka\"]},\n" +
+			"    {\"name\": \"no crash loops\", \"type\": \"max-restarts\", \"max\": 3},\n" +
+			"    {\"name\": \"no critical issues\", \"type\": \"max-issues\", \"severity\": \"critical\", \"max\": 0}\n" +
+			"  ]}\n" +
+			"Exit with the status 1 if any expectation fails and with the status 2 if the check cannot be done.",
+		Args: cobra.ExactArgs(1),
+		Run:  checkExpectations,
+	}
+	checkCmd.Flags().StringP("format", "f", "text",
+		"output format: text, json or junit")
+	checkCmd.Flags().StringP("output", "o", "",
+		"write the results to this file instead of the standard output")
+	rootCmd.AddCommand(checkCmd)
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func checkBundle(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	c, err := tools.CheckBundle(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot check bundle: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteBundleCheck(os.Stdout, c, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	if !c.Complete() {
+		exit(1)
+	}
+}
+
+func init() {
+	checkBundleCmd := &cobra.Command{
+		Use:   "check-bundle",
+		Short: "Check that the bundle is complete",
+		Long: "Compare the bundle with the expected layout of a service diagnostics bundle: the tasks directory, " +
+			"the service configuration, the scheduler files, the Mesos state and the plans. Report missing, empty " +
+			"and unreadable parts, e.g., JSON files truncated because the collection timed out, files which " +
+			"cannot be read because of permissions, and tasks without logs. " +
+			"Exit with the status 1 if the tasks directory or the service configuration is missing or unreadable.",
+		Run: checkBundle,
+	}
+	checkBundleCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	rootCmd.AddCommand(checkBundleCmd)
+}
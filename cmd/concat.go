@@ -3,11 +3,14 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/adyatlov/sbun/tools"
 	"github.com/spf13/cobra"
 )
 
+const concatDiagnosticsFileName = "concat_logs_diagnostics.txt"
+
 func concatLogs(cmd *cobra.Command, _ []string) {
 	compress := true
 	if cmd.Flag("dont-compress").Changed {
@@ -16,14 +19,36 @@ func concatLogs(cmd *cobra.Command, _ []string) {
 	if err := tools.Concat(bundlePath, compress); err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "ERROR: error when concatenating logs: %v", err.Error())
 	}
+	damages := tools.TakeLogDamages()
+	if len(damages) == 0 {
+		return
+	}
+	reportPath := filepath.Join(bundlePath, concatDiagnosticsFileName)
+	f, err := os.Create(reportPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot create diagnostics report: %v\n", err)
+		for _, d := range damages {
+			_, _ = fmt.Fprintf(os.Stderr, "  %v\n", d.String())
+		}
+		return
+	}
+	defer closeCloser(f)
+	for _, d := range damages {
+		_, _ = fmt.Fprintln(f, d.String())
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "Damaged files were kept with the %v suffix, "+
+		"the diagnostics report is written to %v\n", tools.DamagedSuffix, reportPath)
 }
 
 func init() {
 	concatLogsCmd := &cobra.Command{
 		Use:   "concat-logs",
 		Short: "Concatenate task logs to a single file",
-		Long:  "Concatenate all task stdout and stderr logs to a single file: stdout_all, stderr_all",
-		Run:   concatLogs,
+		Long: "Concatenate all task stdout and stderr logs to a single file: stdout_all, stderr_all. " +
+			"Damaged parts of compressed logs are skipped, the damaged files are kept with the " + tools.DamagedSuffix +
+			" suffix and listed in the " + concatDiagnosticsFileName + " file in the bundle directory. " +
+			"Logs which were already concatenated are not overwritten.",
+		Run: concatLogs,
 	}
 	concatLogsCmd.Flags().BoolP("dont-compress", "d", false,
 		"do not compress stdout_all and stderr_all files")
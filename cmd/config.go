@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printConfig(cmd *cobra.Command, _ []string) {
+	c := tools.CurrentConfig()
+	if cmd.Flag("default").Changed {
+		c = tools.DefaultConfig()
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot print config: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Print configuration",
+		Long: "Print the configuration in use in the JSON format. The output can be used as a starting point " +
+			"for a configuration file. Log streams define which files SBun treats as logs: the file name regexp " +
+			"with a rotation index group, the name of the concatenated file and the glob patterns " +
+			"of the sandbox subdirectories with the files; other subdirectories are not searched for logs. " +
+			"Task directory name parsers are tried in order, the first one which accepts a directory name " +
+			"is used; built-in parsers are \"sdk\" for the service diagnostics bundle convention and " +
+			"\"marathon\" for Marathon app task IDs. Custom parsers " +
+			"are regular expressions with the \"name\" and \"id\" groups and optional \"starting\", " +
+			"\"running\", \"killed\" and \"failed\" timestamp groups.",
+		Run: printConfig,
+	}
+	configCmd.Flags().BoolP("default", "d", false,
+		"print the default configuration")
+	rootCmd.AddCommand(configCmd)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func diffBundles(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	d, err := tools.DiffBundles(args[0], args[1])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot compare bundles: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteBundleDiff(os.Stdout, d, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	diffCmd := &cobra.Command{
+		Use:   "diff <before bundle> <after bundle>",
+		Short: "Compare two bundles of the same service",
+		Long: "Compare two bundles of the same service, e.g., collected before and after an incident. " +
+			"Shows the added and removed tasks, changes of the latest task states per pod instance, new restarts, " +
+			"new error signatures in the task logs, and changes in the service configuration and plan files.",
+		Args: cobra.ExactArgs(2),
+		Run:  diffBundles,
+	}
+	diffCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func diskUsage(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	top, _ := cmd.Flags().GetInt("top")
+	exact, _ := cmd.Flags().GetBool("exact")
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	du, err := tools.MeasureDiskUsage(tasks, exact)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot measure disk usage: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteDiskUsage(os.Stdout, du, format, top); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	duCmd := &cobra.Command{
+		Use:   "du",
+		Short: "Show disk usage per task and log stream",
+		Long: "Show compressed and uncompressed size of every task directory grouped by pod type, " +
+			"the size and the number of rotations of every log stream, and the largest files. " +
+			"The CSV output has a row per task and stream, the JSON output has both tasks and files.",
+		Run: diskUsage,
+	}
+	duCmd.Flags().StringP("format", "f", "text",
+		"output format: text, csv or json")
+	duCmd.Flags().IntP("top", "n", 10,
+		"number of the largest files to show, 0 means all")
+	duCmd.Flags().Bool("exact", false,
+		"decompress gzip files to get the exact uncompressed size instead of reading it from the gzip trailer")
+	rootCmd.AddCommand(duCmd)
+}
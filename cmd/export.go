@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func exportBundle(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	output, _ := cmd.Flags().GetString("output")
+	noFindings, _ := cmd.Flags().GetBool("no-findings")
+	signatures, _ := cmd.Flags().GetBool("signatures")
+	if format != "sql" {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: unknown format %q, the only supported format is sql\n", format)
+		exit(1)
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	var writer io.WriteCloser = os.Stdout
+	if output != "" {
+		if writer, err = os.Create(output); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot create output file: %v\n", err)
+			exit(1)
+		}
+	}
+	err = tools.WriteSQL(writer, bundlePath, tasks, tools.SQLOptions{Findings: !noFindings, Signatures: signatures})
+	if output != "" {
+		closeCloser(writer)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot export bundle: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the task list as a SQL dump",
+		Long: "Write SQL statements which create and fill the tables tasks, task_states, log_files, findings, " +
+			"finding_tasks, finding_evidence, analyzer_errors, error_signatures and error_signature_tasks, " +
+			"so the bundle can be queried with joins and window functions in any SQL tool. " +
+			"The dump is SQLite-compatible, e.g.:\n" +
+			"  sbun export -f sql -o bundle.sql && sqlite3 bundle.db < bundle.sql\n" +
+			"Times are UTC strings like 2020-04-16 11:01:49. Findings are the issues found by all the analyzers, " +
+			"see \"sbun analyze\"; finding_tasks and finding_evidence link them to the affected tasks and " +
+			"the evidence. Error signatures of the task logs are exported only with --signatures.",
+		Run: exportBundle,
+	}
+	exportCmd.Flags().StringP("format", "f", "sql",
+		"output format: sql")
+	exportCmd.Flags().StringP("output", "o", "",
+		"write the dump to this file instead of the standard output")
+	exportCmd.Flags().Bool("no-findings", false,
+		"don't run the analyzers")
+	exportCmd.Flags().Bool("signatures", false,
+		"read the task logs to find error signatures")
+	rootCmd.AddCommand(exportCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func extract(cmd *cobra.Command, args []string) {
+	conditions, _ := cmd.Flags().GetStringArray("filter")
+	include, _ := cmd.Flags().GetStringArray("include")
+	filter, err := tools.ParseTaskFilter(conditions)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	opts := tools.ExtractOptions{Filter: filter, Include: include}
+	for name, t := range map[string]*time.Time{"since": &opts.Since, "until": &opts.Until} {
+		f := cmd.Flag(name)
+		if !f.Changed {
+			continue
+		}
+		if *t, err = tools.ParseTimeFlag(f.Value.String()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot parse --%v: %v\n", name, err)
+			exit(1)
+		}
+	}
+	manifest, err := tools.Extract(bundlePath, args[0], opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot extract: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("Extracted %v of %v tasks and %v top-level files and directories to %v\n",
+		len(manifest.Tasks), len(manifest.Tasks)+len(manifest.OmittedTasks), len(manifest.TopLevel), args[0])
+	if len(manifest.CutLogs) != 0 {
+		fmt.Printf("Cut %v log files to the time window\n", len(manifest.CutLogs))
+	}
+}
+
+func init() {
+	extractCmd := &cobra.Command{
+		Use:   "extract <output directory or .tar.gz file>",
+		Short: "Extract a sub-bundle with selected tasks",
+		Long: "Write a new bundle with the top-level files of the bundle and only the tasks matching the filter. " +
+			"If the output path ends with .tar.gz or .tgz, the sub-bundle is written as an archive. " +
+			"With --since and --until, task logs are cut to the time window. The " +
+			tools.ExtractManifestFileName + " file in the sub-bundle lists what was included and omitted.",
+		Args: cobra.ExactArgs(1),
+		Run:  extract,
+	}
+	extractCmd.Flags().StringArrayP("filter", "f", nil,
+		"task filter condition, e.g., pod=kafka-2, state=failed; can be repeated; see the view command")
+	extractCmd.Flags().StringArrayP("include", "i", nil,
+		"glob pattern of a top-level directory to include; can be repeated")
+	extractCmd.Flags().String("since", "",
+		"keep only log lines logged at or after this time, e.g., 2020-04-16T11:01:49")
+	extractCmd.Flags().String("until", "",
+		"keep only log lines logged before this time, e.g., 2020-04-16T11:01:49")
+	rootCmd.AddCommand(extractCmd)
+}
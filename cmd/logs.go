@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printLogs(cmd *cobra.Command, args []string) {
+	stream, _ := cmd.Flags().GetString("stream")
+	usePager, _ := cmd.Flags().GetBool("pager")
+	window := tools.LogWindow{}
+	window.Head, _ = cmd.Flags().GetInt("head")
+	window.Tail, _ = cmd.Flags().GetInt("tail")
+	for name, t := range map[string]*time.Time{"since": &window.Since, "until": &window.Until} {
+		f := cmd.Flag(name)
+		if !f.Changed {
+			continue
+		}
+		var err error
+		if *t, err = tools.ParseTimeFlag(f.Value.String()); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot parse --%v: %v\n", name, err)
+			exit(1)
+		}
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	task, err := tools.FindTask(tasks, args[0])
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	r, err := tools.OpenTaskLog(task, stream)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot open logs of the task %v: %v\n", task.DirName, err)
+		exit(1)
+	}
+	defer closeCloser(r)
+	var out io.Writer = os.Stdout
+	var pager *exec.Cmd
+	if usePager && !outputRedirectedToFile() {
+		pager, out, err = startPager()
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot start pager: %v\n", err)
+			exit(1)
+		}
+	}
+	untimed, err := tools.CopyLog(out, r, window)
+	if pager != nil {
+		closeCloser(out.(io.Closer))
+		_ = pager.Wait()
+	}
+	// The pager closes its input when the user quits it before the end of the log, it is not an error.
+	if err != nil && !errors.Is(err, syscall.EPIPE) {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot read logs of the task %v: %v\n", task.DirName, err)
+		exit(1)
+	}
+	if untimed > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: %v lines before the first line with a timestamp were printed "+
+			"regardless of --since and --until\n", untimed)
+	}
+}
+
+// startPager starts the program from the PAGER environment variable or less and returns its standard input.
+func startPager() (*exec.Cmd, io.WriteCloser, error) {
+	pagerCmd := os.Getenv("PAGER")
+	if pagerCmd == "" {
+		pagerCmd = "less"
+	}
+	var pager *exec.Cmd
+	if runtime.GOOS == "windows" {
+		pager = exec.Command("cmd", "/C", pagerCmd)
+	} else {
+		pager = exec.Command("sh", "-c", pagerCmd)
+	}
+	pager.Stdout = os.Stdout
+	pager.Stderr = os.Stderr
+	in, err := pager.StdinPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := pager.Start(); err != nil {
+		return nil, nil, err
+	}
+	return pager, in, nil
+}
+
+func init() {
+	logsCmd := &cobra.Command{
+		Use:   "logs <task ID, ID prefix or name>",
+		Short: "Print task logs",
+		Long: "Print stdout or stderr of the task, reading all the log rotations in the order they were written. " +
+			"If there are several tasks with the given name, the latest one is used.",
+		Args: cobra.ExactArgs(1),
+		Run:  printLogs,
+	}
+	logsCmd.Flags().StringP("stream", "s", tools.StreamStdout,
+		"log stream to print: stdout, stderr or another configured stream")
+	logsCmd.Flags().Int("head", 0,
+		"print only the first N lines")
+	logsCmd.Flags().Int("tail", 0,
+		"print only the last N lines")
+	logsCmd.Flags().String("since", "",
+		"print only lines logged at or after this time, e.g., 2020-04-16T11:01:49")
+	logsCmd.Flags().String("until", "",
+		"print only lines logged before this time, e.g., 2020-04-16T11:01:49")
+	logsCmd.Flags().Bool("pager", false,
+		"pipe the output to $PAGER or less")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printOverview(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	top, _ := cmd.Flags().GetInt("top")
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	o, err := tools.BuildOverview(bundlePath, tasks, top)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot build overview: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteOverview(os.Stdout, o, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	overviewCmd := &cobra.Command{
+		Use:   "overview",
+		Short: "Show a one-page overview of the bundle",
+		Long: "Show the service name, package and version from the service configuration, the time range of " +
+			"the task timestamps, the number of tasks by state and pod type, restarting pod instances, tasks " +
+			"without logs, the most common error signatures and the largest logs.",
+		Run: printOverview,
+	}
+	overviewCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	overviewCmd.Flags().IntP("top", "n", 5,
+		"number of error signatures and largest logs to show, 0 means all")
+	rootCmd.AddCommand(overviewCmd)
+}
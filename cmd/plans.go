@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printPlans(cmd *cobra.Command, args []string) {
+	format, _ := cmd.Flags().GetString("format")
+	plans, err := tools.FindPlans(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find plans: %v\n", err)
+		exit(1)
+	}
+	if len(args) != 0 {
+		selected := make([]tools.Plan, 0, len(args))
+		for _, plan := range plans {
+			if containsString(args, plan.Name) {
+				selected = append(selected, plan)
+			}
+		}
+		plans = selected
+	}
+	if len(plans) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "No plans found.")
+		return
+	}
+	if err := tools.WritePlans(os.Stdout, plans, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	plansCmd := &cobra.Command{
+		Use:   "plans [plan name]...",
+		Short: "Show service plans",
+		Long: "Show the plan, phase and step tree with statuses, strategies and errors of the scheduler plans " +
+			"found in the bundle, i.e., JSON files with \"plan\" in their path. Plans, phases and steps " +
+			"with the PENDING, WAITING or ERROR status are marked with \"<<\".",
+		Run: printPlans,
+	}
+	plansCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	rootCmd.AddCommand(plansCmd)
+}
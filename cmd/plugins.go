@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+// addPluginCommands adds a subcommand for every plugin on PATH which doesn't clash with a built-in command.
+func addPluginCommands() {
+	for _, p := range tools.FindPlugins() {
+		if cmd, _, err := rootCmd.Find([]string{p.Name}); err == nil && cmd != rootCmd {
+			continue
+		}
+		plugin := p
+		rootCmd.AddCommand(&cobra.Command{
+			Use:   plugin.Name,
+			Short: "Plugin " + plugin.Path,
+			Long: "Run the plugin " + plugin.Path + ". The plugin gets the parsed tasks of the bundle as JSON " +
+				"on the standard input and the absolute bundle path in the " + tools.PluginBundleEnv +
+				" environment variable. The --path and --config flags are used by SBun, all other arguments " +
+				"are passed to the plugin.",
+			DisableFlagParsing: true,
+			// The root pre-run would load the configuration before the --config flag is parsed,
+			// runPlugin loads it instead.
+			PersistentPreRun: func(*cobra.Command, []string) {},
+			Run: func(cmd *cobra.Command, args []string) {
+				runPlugin(plugin, args)
+			},
+		})
+	}
+}
+
+func runPlugin(p tools.Plugin, args []string) {
+	args = parsePluginGlobalFlags(args)
+	loadConfig(nil, nil)
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	input, err := tools.NewPluginInput(bundlePath, tasks)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot prepare the plugin input: %v\n", err)
+		exit(1)
+	}
+	if err := tools.RunPlugin(p, input, args, os.Stdout, os.Stderr); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exit(exitErr.ExitCode())
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot run plugin %v: %v\n", p.Path, err)
+		exit(1)
+	}
+}
+
+// parsePluginGlobalFlags sets the bundle and the configuration paths from the --path and --config flags
+// and returns the other arguments. Flag parsing is disabled for plugin commands, so that the plugins can
+// have their own flags. Arguments after "--" are not parsed.
+func parsePluginGlobalFlags(args []string) []string {
+	flags := map[string]*string{"-p": &bundlePath, "--path": &bundlePath, "-c": &configPath, "--config": &configPath}
+	rest := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i+1:]...)
+			break
+		}
+		name, value, hasValue := arg, "", false
+		if j := strings.Index(arg, "="); j > 0 {
+			name, value, hasValue = arg[:j], arg[j+1:], true
+		}
+		dst, ok := flags[name]
+		if !ok || !hasValue && i+1 == len(args) {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			i++
+			value = args[i]
+		}
+		*dst = value
+	}
+	return rest
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func Test_parsePluginGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantRest   []string
+		wantBundle string
+		wantConfig string
+	}{
+		{"passes plugin arguments", []string{"--top", "3", "-v"}, []string{"--top", "3", "-v"}, "wd", ""},
+		{"parses separate values", []string{"-p", "bundle", "--top", "3", "--config", "sbun.json"},
+			[]string{"--top", "3"}, "bundle", "sbun.json"},
+		{"parses values after =", []string{"--path=bundle", "-c=sbun.json", "x"}, []string{"x"}, "bundle", "sbun.json"},
+		{"keeps a flag without a value", []string{"x", "--path"}, []string{"x", "--path"}, "wd", ""},
+		{"doesn't parse after --", []string{"-p", "bundle", "--", "-p", "plugin"}, []string{"-p", "plugin"}, "bundle", ""},
+	}
+	defer func(b string, c string) { bundlePath, configPath = b, c }(bundlePath, configPath)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bundlePath, configPath = "wd", ""
+			rest := parsePluginGlobalFlags(tt.args)
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("parsePluginGlobalFlags() = %q, want %q", rest, tt.wantRest)
+			}
+			if bundlePath != tt.wantBundle || configPath != tt.wantConfig {
+				t.Errorf("parsePluginGlobalFlags() set path %q and config %q, want %q and %q",
+					bundlePath, configPath, tt.wantBundle, tt.wantConfig)
+			}
+		})
+	}
+}
+
+func Test_runPlugin_config(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the user configuration directory is set with XDG_CONFIG_HOME on Linux")
+	}
+	dir, err := ioutil.TempDir("", "sbun-plugin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	bundle := filepath.Join(dir, "bundle")
+	files := map[string]string{
+		// The default configuration file is invalid, the one given to the plugin command is valid.
+		filepath.Join(dir, "config", tools.ConfigFileName): `{"logStreams": []}`,
+		filepath.Join(dir, "valid.json"):                   `{}`,
+		filepath.Join(dir, "bin", "sbun-test"):             "#!/bin/sh\ncat > \"$SBUN_BUNDLE/input.json\"\n",
+	}
+	taskDir := "starting_20200416T110000__kafka-0-broker__kafka-0-broker__a"
+	files[filepath.Join(bundle, tools.DirNameTasks, taskDir, "stdout")] = "log\n"
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := map[string]string{
+		"XDG_CONFIG_HOME": filepath.Join(dir, "config"),
+		"PATH":            filepath.Join(dir, "bin") + string(filepath.ListSeparator) + os.Getenv("PATH"),
+	}
+	for name, value := range env {
+		defer func(name string, value string) { _ = os.Setenv(name, value) }(name, os.Getenv(name))
+		if err := os.Setenv(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(b string, c string) { bundlePath, configPath = b, c }(bundlePath, configPath)
+	addPluginCommands()
+	cmd, _, err := rootCmd.Find([]string{"test"})
+	if err != nil || cmd == rootCmd {
+		t.Fatalf("plugin command is not added: %v", err)
+	}
+	defer rootCmd.RemoveCommand(cmd)
+	// The configuration errors exit the process, so the test fails if the default file is loaded.
+	rootCmd.SetArgs([]string{"test", "--config", filepath.Join(dir, "valid.json"), "-p", bundle})
+	defer rootCmd.SetArgs(nil)
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(bundle, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := tools.PluginInput{}
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatal(err)
+	}
+	if len(input.Tasks) != 1 || input.Tasks[0].Name != "kafka-0-broker" {
+		t.Errorf("plugin input = %+v, want the task of the bundle", input)
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func redact(cmd *cobra.Command, args []string) {
+	patterns, _ := cmd.Flags().GetStringArray("detector")
+	copyBinary, _ := cmd.Flags().GetBool("copy-binary")
+	mappingPath, _ := cmd.Flags().GetString("mapping")
+	detectors := make([]tools.Detector, 0, len(patterns)+len(tools.DefaultDetectors))
+	for _, p := range patterns {
+		d, err := tools.ParseDetector(p)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+		detectors = append(detectors, d)
+	}
+	// Custom detectors go first in the given order, they are usually more specific than the built-in ones.
+	detectors = append(detectors, tools.DefaultDetectors...)
+	redactor := tools.NewRedactor(detectors)
+	report, err := tools.RedactBundle(bundlePath, args[0], redactor, copyBinary)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot redact the bundle: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("Redacted files: %v\n", len(report.Redacted))
+	for _, c := range redactor.SortedCounts() {
+		fmt.Printf("  %v: %v occurrences\n", c.Name, c.Count)
+	}
+	if len(report.Copied) != 0 {
+		fmt.Printf("Binary files copied without redaction: %v\n", len(report.Copied))
+		for _, path := range report.Copied {
+			fmt.Printf("  %v\n", path)
+		}
+	}
+	if len(report.Omitted) != 0 {
+		fmt.Printf("Omitted files: %v\n", len(report.Omitted))
+		for _, path := range report.Omitted {
+			fmt.Printf("  %v\n", path)
+		}
+	}
+	if mappingPath == "" {
+		return
+	}
+	f, err := os.Create(mappingPath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot create mapping file: %v\n", err)
+		exit(1)
+	}
+	defer closeCloser(f)
+	if err := redactor.WriteMapping(f); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot write mapping file: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	redactCmd := &cobra.Command{
+		Use:   "redact <output directory>",
+		Short: "Write a copy of the bundle with secrets and personal data replaced",
+		Long: "Copy all text files of the bundle, including compressed logs, to the output directory " +
+			"with JWTs, AWS keys, passwords, tokens, IPs, emails and custom patterns replaced by placeholders. " +
+			"The same value is always replaced by the same placeholder, e.g., [IP-1]. The directory layout " +
+			"and file names, including log rotations, are preserved. Binary files are omitted by default.",
+		Args: cobra.ExactArgs(1),
+		Run:  redact,
+	}
+	redactCmd.Flags().StringArrayP("detector", "d", nil,
+		"custom detector in the <name>=<regexp> format, if the regexp has a group, only the group is replaced; "+
+			"can be repeated")
+	redactCmd.Flags().Bool("copy-binary", false,
+		"copy binary files without redaction")
+	redactCmd.Flags().StringP("mapping", "m", "",
+		"write placeholders and original values to this file, keep it private")
+	rootCmd.AddCommand(redactCmd)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func writeReport(cmd *cobra.Command, _ []string) {
+	html, _ := cmd.Flags().GetBool("html")
+	markdown, _ := cmd.Flags().GetBool("markdown")
+	output, _ := cmd.Flags().GetString("output")
+	opts := tools.DefaultReportOptions
+	opts.Findings, _ = cmd.Flags().GetInt("findings")
+	markdownOpts := tools.DefaultMarkdownOptions
+	markdownOpts.MaxLength, _ = cmd.Flags().GetInt("max-length")
+	markdownOpts.ExcerptLines, _ = cmd.Flags().GetInt("excerpt-lines")
+	if html == markdown {
+		_, _ = fmt.Fprintln(os.Stderr, "ERROR: Please choose the report format with either the --html or "+
+			"the --markdown flag.")
+		exit(1)
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	report, err := tools.BuildReport(bundlePath, tasks, opts)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot build report: %v\n", err)
+		exit(1)
+	}
+	writer := os.Stdout
+	if output != "" {
+		if writer, err = os.Create(output); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: Cannot create file: %v\n", err)
+			exit(1)
+		}
+		defer closeCloser(writer)
+	}
+	if markdown {
+		err = tools.WriteReportMarkdown(writer, report, markdownOpts)
+	} else {
+		err = tools.WriteReportHTML(writer, report)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot write report: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	reportCmd := &cobra.Command{
+		Use:   "report",
+		Short: "Write a report to attach to a support case",
+		Long: "Write a report with the bundle summary, a sortable task table, the task timeline, the restart " +
+			"history of every restarted task, the most common error signatures with log excerpts and the largest " +
+			"logs. The HTML report is a single file with embedded styles and scripts, it can be viewed offline. " +
+			"Links to the log files work if the report is saved in the bundle directory. " +
+			"The Markdown report is a concise summary to paste into a support case comment: the state counts, " +
+			"failing pod instances, top findings with a few log lines and a compact timeline. Findings and " +
+			"timeline rows which don't fit into the length limit are omitted.",
+		Run: writeReport,
+	}
+	reportCmd.Flags().Bool("html", false,
+		"write the report in HTML")
+	reportCmd.Flags().Bool("markdown", false,
+		"write the report in Markdown")
+	reportCmd.Flags().Int("max-length", tools.DefaultMarkdownOptions.MaxLength,
+		"maximum length of the Markdown report in bytes, 0 means no limit")
+	reportCmd.Flags().Int("excerpt-lines", tools.DefaultMarkdownOptions.ExcerptLines,
+		"number of log lines to show for every finding in the Markdown report")
+	reportCmd.Flags().StringP("output", "o", "",
+		"path to the output file, the standard output by default")
+	reportCmd.Flags().IntP("findings", "n", tools.DefaultReportOptions.Findings,
+		"number of error signatures to include, 0 means all")
+	rootCmd.AddCommand(reportCmd)
+}
@@ -5,10 +5,13 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
 )
 
 var (
 	bundlePath string
+	configPath string
 )
 
 var rootCmd = &cobra.Command{
@@ -16,22 +19,52 @@ var rootCmd = &cobra.Command{
 	Short: "Service diagnostics bundle analysis tool",
 	Long: "SBun is a CLI tool which helps to analyze DC/OS service diagnostics bundle: " +
 		"https://support.d2iq.com/s/article/create-service-diag-bundle",
+	PersistentPreRun:  loadConfig,
+	PersistentPostRun: printLogDamages,
+}
+
+func loadConfig(*cobra.Command, []string) {
+	if err := tools.LoadConfig(configPath); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
 }
 
 func init() {
 	wd, err := os.Getwd()
 	if err != nil {
 		fmt.Printf("Error while detecting a working directory: %v\n", err.Error())
-		os.Exit(1)
+		exit(1)
 	}
 	rootCmd.PersistentFlags().StringVarP(&bundlePath, "path", "p", wd,
 		"path to the bundle directory")
+	rootCmd.PersistentFlags().StringVarP(&configPath, "config", "c", "",
+		"path to the configuration file, by default <user config dir>/"+tools.ConfigFileName+" is used if it exists")
+}
+
+// printLogDamages warns about log files which were read only partially.
+func printLogDamages(*cobra.Command, []string) {
+	damages := tools.TakeLogDamages()
+	if len(damages) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "WARNING: %v damaged parts of log files were skipped:\n", len(damages))
+	for _, d := range damages {
+		_, _ = fmt.Fprintf(os.Stderr, "  %v\n", d.String())
+	}
+}
+
+// exit prints the log damages, which PersistentPostRun doesn't print if the command exits, and exits with the code.
+func exit(code int) {
+	printLogDamages(nil, nil)
+	os.Exit(code)
 }
 
 // Execute starts Bun.
 func Execute() {
+	addPluginCommands()
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func analyzeScheduler(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	kinds, _ := cmd.Flags().GetStringSlice("event")
+	taskQuery, _ := cmd.Flags().GetString("task")
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	schedulers := tools.SchedulerTasks(tasks)
+	if taskQuery != "" {
+		task, err := tools.FindTask(tasks, taskQuery)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			exit(1)
+		}
+		schedulers = []tools.Task{task}
+	}
+	a, err := tools.AnalyzeSchedulerLogs(bundlePath, schedulers)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot analyze scheduler logs: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteSchedulerAnalysis(os.Stdout, a, format, kinds); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	schedulerCmd := &cobra.Command{
+		Use:   "scheduler",
+		Short: "Summarize scheduler logs",
+		Long: "Parse the logs of the SDK scheduler and show the counts and the timeline of offer evaluations " +
+			"with the reason of failed placements, plan step status changes, task reconciliations and framework " +
+			"registrations. Consecutive events with the same outcome are collapsed into a single timeline entry. " +
+			"The scheduler logs are the logs of the tasks which don't follow the <pod type>-<index>-<task> naming " +
+			"convention and the log stream files in the \"" + tools.DirNameScheduler + "\" directory of the bundle.",
+		Run: analyzeScheduler,
+	}
+	schedulerCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	schedulerCmd.Flags().StringSliceP("event", "e", nil,
+		"show only events of these kinds: "+tools.EventOffer+", "+tools.EventPlanStep+", "+
+			tools.EventReconciliation+", "+tools.EventRegistration)
+	schedulerCmd.Flags().StringP("task", "t", "",
+		"scheduler task ID, ID prefix, name or directory name; by default all the tasks which look like schedulers")
+	rootCmd.AddCommand(schedulerCmd)
+}
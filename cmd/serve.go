@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func serveBundle(cmd *cobra.Command, _ []string) {
+	address, _ := cmd.Flags().GetString("address")
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("Serving the bundle %v at http://%v/, press Ctrl+C to stop.\n", bundlePath, address)
+	if err := http.ListenAndServe(address, tools.NewServer(bundlePath, tasks)); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot serve: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	serveCmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Browse the bundle in a web browser",
+		Long: "Start a local HTTP server with a web UI for browsing the bundle: the task list with filters, " +
+			"the log viewer which reads all the log rotations with paging and search, the task timeline and " +
+			"the plans. The UI doesn't load anything from the Internet.\n\n" +
+			"The UI is backed by a JSON API which scripts can use too:\n" +
+			"  GET /api/tasks?filter=state=failed&latest=true\n" +
+			"  GET /api/tasks/<task>/logs?stream=stdout&offset=0&limit=500&search=error\n" +
+			"  GET /api/timeline\n" +
+			"  GET /api/plans\n" +
+			"  GET /api/streams\n" +
+			"Filter conditions are the same as in the --filter flags of other commands, the task is " +
+			"a task ID, ID prefix, name or directory name.",
+		Run: serveBundle,
+	}
+	serveCmd.Flags().StringP("address", "a", "localhost:8080",
+		"address to listen on, use a loopback address to keep the bundle private")
+	rootCmd.AddCommand(serveCmd)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func summarizeLogs(cmd *cobra.Command, _ []string) {
+	stream, _ := cmd.Flags().GetString("stream")
+	byTask, _ := cmd.Flags().GetBool("by-task")
+	top, _ := cmd.Flags().GetInt("top")
+	similarity, _ := cmd.Flags().GetFloat64("similarity")
+	streams := tools.LogStreamNames()
+	if stream != "all" {
+		streams = []string{stream}
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	groups := [][]tools.Task{tasks}
+	if byTask {
+		groups = groups[:0]
+		for _, task := range tasks {
+			groups = append(groups, []tools.Task{task})
+		}
+	}
+	for _, group := range groups {
+		templates, err := tools.MineTaskTemplates(group, streams, similarity)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot summarize logs: %v\n", err)
+			exit(1)
+		}
+		if len(templates) == 0 {
+			continue
+		}
+		if byTask {
+			fmt.Printf("== %v\n", group[0].DirName)
+		}
+		if err := tools.WriteTemplates(os.Stdout, templates, top); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot write templates: %v\n", err)
+			exit(1)
+		}
+		if byTask {
+			fmt.Println()
+		}
+	}
+}
+
+func init() {
+	summarizeLogsCmd := &cobra.Command{
+		Use:   "summarize-logs",
+		Short: "Summarize task logs as a list of message templates",
+		Long: "Group log lines which differ only in numbers, IDs, IPs and UUIDs into templates " +
+			"and print the templates ordered by frequency with an example line and the time range " +
+			"in which the lines occurred.",
+		Run: summarizeLogs,
+	}
+	summarizeLogsCmd.Flags().StringP("stream", "s", "all",
+		"log stream to summarize: stdout, stderr, another configured stream or all")
+	summarizeLogsCmd.Flags().BoolP("by-task", "t", false,
+		"summarize logs of each task separately")
+	summarizeLogsCmd.Flags().IntP("top", "n", 0,
+		"print only N most frequent templates, 0 means all")
+	summarizeLogsCmd.Flags().Float64("similarity", tools.DefaultSimilarity,
+		"minimal share of equal tokens for a line to match a template, from 0 to 1")
+	rootCmd.AddCommand(summarizeLogsCmd)
+}
@@ -16,7 +16,7 @@ func printTasks(cmd *cobra.Command, _ []string) {
 	if o.Changed && O.Changed {
 		_, _ = fmt.Fprintln(os.Stderr, "ERROR: Flags -o (--output) and -O (--default-name) are mutually exclusive. "+
 			"Please use only one of them.")
-		os.Exit(1)
+		exit(1)
 	}
 	var err error
 	if o.Changed {
@@ -27,9 +27,14 @@ func printTasks(cmd *cobra.Command, _ []string) {
 	}
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "ERROR: Cannot create file: %v", err.Error())
-		os.Exit(1)
+		exit(1)
 	}
-	err = tools.WriteCsv(bundlePath, writer)
+	bundles, err := tools.FindBundles(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
+		exit(1)
+	}
+	err = tools.WriteCsv(bundles, !tools.IsBundle(bundlePath), writer)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err.Error())
 	}
@@ -41,7 +46,12 @@ func init() {
 		Short: "Print service task list",
 		Long: "Print service task list in the CSV format to the standard output or file. The order of columns is: " +
 			"<task name>, <starting timestamp>, <running timestamp>, <killed timestamp>, <failed timestamp>, <task ID>, " +
-			"<has logs>, <path to the task directory>",
+			"<has logs>, <path to the task directory>, <agent hostname>, <agent ID>, <framework ID>, <resources>, " +
+			"<container type>, <last status reason>, <last status message>. The agent, framework, resources, " +
+			"container and status columns are taken from the Mesos state files in the bundle and are empty " +
+			"if the task is not found there.\n\n" +
+			"If --path is a glob pattern or a directory with bundles, tasks of all the bundles are printed " +
+			"and the first column is <bundle path>.",
 		Run: printTasks,
 	}
 	taskCsvCmd.Flags().StringP("output", "o", "",
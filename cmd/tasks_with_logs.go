@@ -11,36 +11,24 @@ import (
 
 func tasksWithLogs(cmd *cobra.Command, _ []string) {
 	f := cmd.Flag("save-to")
-	oldDir := filepath.Join("..", tools.DirNameTasks)
-	newDir := filepath.Join(bundlePath, "tasks_with_logs")
+	newDir := filepath.Join(bundlePath, tools.TasksWithLogsDirName)
 	if f.Changed {
-		oldDir = filepath.Join(bundlePath, tools.DirNameTasks)
-		newDir = filepath.Join(f.Value.String(), "tasks_with_logs")
+		newDir = filepath.Join(f.Value.String(), tools.TasksWithLogsDirName)
 	}
 	tasks, err := tools.FindTasks(bundlePath)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "Cannot find tasks: %v", err)
 		return
 	}
+	filter, _ := tools.ParseTaskFilter([]string{"has-logs=true"})
+	tasks = filter.Filter(tasks)
 	if len(tasks) == 0 {
 		_, _ = fmt.Fprintln(os.Stderr, "No tasks with logs found.")
 		return
 	}
-	if err := os.RemoveAll(newDir); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Cannot remove directory: %v\n", err)
-	}
-	if err := os.MkdirAll(newDir, 0777); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Cannot create directory: %v\n", err)
-	}
-	for _, task := range tasks {
-		if !task.HasLogs {
-			continue
-		}
-		oldDirName := filepath.Join(oldDir, task.DirName)
-		newDirName := filepath.Join(newDir, task.DirName)
-		if err := os.Symlink(oldDirName, newDirName); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Cannot create link: %v", err)
-		}
+	mode, _ := cmd.Flags().GetString("mode")
+	if err := tools.BuildView(bundlePath, newDir, tasks, tools.ViewOptions{RawNames: true, Mode: mode}); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n", err)
 	}
 }
 
@@ -49,10 +37,14 @@ func init() {
 		Use:   "tasks-with-logs",
 		Short: "Find tasks which have logs",
 		Long: "The command creates a tasks_with_logs directory and a sym-link in this directory to each task which has logs. " +
-			"By default, it create links with relative paths in <bundle path>/tasks_with_logs.",
+			"By default, it create links with relative paths in <bundle path>/tasks_with_logs. " +
+			"It is a shortcut for: sbun view tasks_with_logs --filter has-logs=true --raw-names. " +
+			"See the view command for the link modes.",
 		Run: tasksWithLogs,
 	}
 	taskCsvCmd.Flags().StringP("save-to", "s", "",
 		"path to the directory in which the command creates a tasks_with_logs direcoty")
+	taskCsvCmd.Flags().StringP("mode", "m", tools.LinkSymlink,
+		"how to link tasks: symlink, hardlink or copy")
 	rootCmd.AddCommand(taskCsvCmd)
 }
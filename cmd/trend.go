@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func printTrend(cmd *cobra.Command, _ []string) {
+	format, _ := cmd.Flags().GetString("format")
+	top, _ := cmd.Flags().GetInt("top")
+	bundles, err := tools.FindBundles(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	trend, err := tools.BuildTrend(bundles, top)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot build trend: %v\n", err)
+		exit(1)
+	}
+	if err := tools.WriteTrend(os.Stdout, trend, format); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+}
+
+func init() {
+	trendCmd := &cobra.Command{
+		Use:   "trend",
+		Short: "Show how tasks, restarts and errors change across bundles",
+		Long: "Show the task counts by state, restarts and error signatures of several bundles of the same " +
+			"service ordered by the collection time, which is the latest task timestamp of the bundle. " +
+			"Set --path to a glob pattern, e.g., -p 'customer/bundle-*', or to a directory with bundles. " +
+			"Error signatures are matched across bundles by their template.",
+		Run: printTrend,
+	}
+	trendCmd.Flags().StringP("format", "f", "text",
+		"output format: text or json")
+	trendCmd.Flags().IntP("top", "n", 10,
+		"number of error signatures to show, 0 means all")
+	rootCmd.AddCommand(trendCmd)
+}
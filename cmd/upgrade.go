@@ -50,7 +50,7 @@ func CheckNewRelease() {
 	fmt.Println("Upgrading...")
 	if err := gh.upgradeExecutable("sbun"); err != nil {
 		fmt.Println("Couldn't upgrade to the newer version:", err.Error())
-		os.Exit(1)
+		exit(1)
 	}
 	fmt.Println("Successfully upgraded to the newer version.")
 	cmd := exec.Command(os.Args[0], os.Args[1:]...)
@@ -59,10 +59,10 @@ func CheckNewRelease() {
 	if err := cmd.Run(); err != nil {
 		if _, ok := err.(*exec.ExitError); !ok {
 			fmt.Println("Couldn't launch to the newer version:", err.Error())
-			os.Exit(1)
+			exit(1)
 		}
 	}
-	os.Exit(0)
+	exit(0)
 }
 
 type gitHub struct {
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/adyatlov/sbun/tools"
+)
+
+func buildView(cmd *cobra.Command, args []string) {
+	conditions, _ := cmd.Flags().GetStringArray("filter")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	latest, _ := cmd.Flags().GetBool("latest")
+	rawNames, _ := cmd.Flags().GetBool("raw-names")
+	saveTo, _ := cmd.Flags().GetString("save-to")
+	mode, _ := cmd.Flags().GetString("mode")
+	filter, err := tools.ParseTaskFilter(conditions)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	tasks, err := tools.FindTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: cannot find tasks: %v\n", err)
+		exit(1)
+	}
+	tasks = filter.Filter(tasks)
+	if latest {
+		tasks = tools.LatestRunPerInstance(tasks)
+	}
+	if len(tasks) == 0 {
+		_, _ = fmt.Fprintln(os.Stderr, "No tasks match the filter.")
+		return
+	}
+	viewDir := filepath.Join(bundlePath, args[0])
+	if saveTo != "" {
+		viewDir = filepath.Join(saveTo, args[0])
+	}
+	opts := tools.ViewOptions{GroupBy: groupBy, RawNames: rawNames, Mode: mode}
+	if err := tools.BuildView(bundlePath, viewDir, tasks, opts); err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("Linked %v tasks in %v\n", len(tasks), viewDir)
+}
+
+func init() {
+	viewCmd := &cobra.Command{
+		Use:   "view <view name>",
+		Short: "Create a directory with links to selected tasks",
+		Long: "Create a directory with a symbolic link to each task matching the filter, optionally grouped " +
+			"into subdirectories by a task field. Links are named after the task name, start time, state " +
+			"and ID prefix. By default, the directory is created in the bundle directory and the links are " +
+			"relative. Where symbolic links are not supported or not followed, use the hardlink or copy mode: " +
+			"it recreates the task directories with hard links to the files or with copies of the files; " +
+			"files which cannot be hard linked, e.g., because they are on another device, are copied; symbolic " +
+			"links to files are replaced with the files, other symbolic links are skipped. " +
+			"The " + tools.ViewMarkerFileName + " manifest in the view directory records what was linked. " +
+			"An existing directory is replaced only if it was created by SBun.\n\n" +
+			"Examples:\n" +
+			"  sbun view by-pod --group-by pod\n" +
+			"  sbun view failed --filter state=failed --group-by day\n" +
+			"  sbun view latest-brokers --filter 'name=kafka-*-broker' --latest\n\n" +
+			"Task fields: " + strings.Join(tools.TaskFieldNames(), ", "),
+		Args: cobra.ExactArgs(1),
+		Run:  buildView,
+	}
+	viewCmd.Flags().StringArrayP("filter", "f", nil,
+		"task filter condition, e.g., state=failed, name!=kafka-*, started>2020-04-16T11:00; can be repeated")
+	viewCmd.Flags().StringP("group-by", "g", "",
+		"task field to group links by, e.g., pod, pod-type, state or day")
+	viewCmd.Flags().BoolP("latest", "l", false,
+		"link only the latest run of every pod instance task")
+	viewCmd.Flags().Bool("raw-names", false,
+		"name links after the task directories")
+	viewCmd.Flags().StringP("mode", "m", tools.LinkSymlink,
+		"how to link tasks: symlink, hardlink or copy")
+	viewCmd.Flags().StringP("save-to", "s", "",
+		"path to the directory in which the command creates the view directory")
+	rootCmd.AddCommand(viewCmd)
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// UnknownAgent is the agent of the tasks which are not found in the Mesos state.
+const UnknownAgent = "unknown"
+
+// An agent is an outlier if it has at least outlierMinFailures failed tasks and its failure rate is at least
+// outlierFactor times the failure rate of the other agents together.
+const (
+	outlierMinFailures = 2
+	outlierFactor      = 2.0
+)
+
+// AgentSummary is the task statistics of an agent.
+type AgentSummary struct {
+	Agent   string `json:"agent"`
+	AgentID string `json:"agentId"`
+	Tasks   int    `json:"tasks"`
+	Running int    `json:"running"`
+	Failed  int    `json:"failed"`
+	Killed  int    `json:"killed"`
+	// Restarts is the number of tasks which are not the first run of the task name.
+	Restarts    int     `json:"restarts"`
+	FailureRate float64 `json:"failureRate"`
+	RestartRate float64 `json:"restartRate"`
+	// Outlier is true if the failure rate of the agent is much higher than the failure rate of the other agents.
+	Outlier bool `json:"outlier"`
+	// ErrorSignatures are the error signatures of the tasks on the agent, ordered by the number of
+	// affected tasks.
+	ErrorSignatures []AgentErrorSignature `json:"errorSignatures"`
+}
+
+// AgentErrorSignature is an error signature found in the tasks of an agent.
+type AgentErrorSignature struct {
+	Template string `json:"template"`
+	// Tasks is the number of tasks on the agent with the signature.
+	Tasks int `json:"tasks"`
+}
+
+// agentKey returns the agent the task ran on: its hostname, its ID if the hostname is unknown,
+// or UnknownAgent if the task is not in the Mesos state.
+func agentKey(t Task) string {
+	m := t.mesos()
+	if m.AgentHost != "" {
+		return m.AgentHost
+	}
+	if m.AgentID != "" {
+		return m.AgentID
+	}
+	return UnknownAgent
+}
+
+// SummarizeAgents groups the tasks by the agent they ran on according to the Mesos state and returns
+// the agent statistics ordered by the failure rate. Tasks which are not in the Mesos state are grouped
+// under UnknownAgent, which is never an outlier. If signatures is false, error signatures are not mined.
+func SummarizeAgents(tasks []Task, signatures bool) ([]AgentSummary, error) {
+	byAgent := make(map[string]*AgentSummary)
+	agentOfTask := make(map[string]string)
+	for _, t := range tasks {
+		agent := agentKey(t)
+		agentOfTask[t.DirName] = agent
+		s, ok := byAgent[agent]
+		if !ok {
+			s = &AgentSummary{Agent: agent, AgentID: t.mesos().AgentID, ErrorSignatures: []AgentErrorSignature{}}
+			byAgent[agent] = s
+		}
+		s.Tasks++
+		switch t.State() {
+		case StateRunning:
+			s.Running++
+		case StateFailed:
+			s.Failed++
+		case StateKilled:
+			s.Killed++
+		}
+	}
+	for _, t := range restartedTasks(tasks) {
+		byAgent[agentOfTask[t.DirName]].Restarts++
+	}
+	if signatures {
+		templates, err := MineErrorSignatures(tasks)
+		if err != nil {
+			return nil, err
+		}
+		for _, template := range templates {
+			affected := make(map[string]int)
+			for _, source := range template.Sources {
+				affected[agentOfTask[source]]++
+			}
+			for agent, n := range affected {
+				if s, ok := byAgent[agent]; ok {
+					s.ErrorSignatures = append(s.ErrorSignatures, AgentErrorSignature{template.Template, n})
+				}
+			}
+		}
+	}
+	summaries := make([]AgentSummary, 0, len(byAgent))
+	totalTasks, totalFailed := 0, 0
+	for _, s := range byAgent {
+		s.FailureRate = float64(s.Failed) / float64(s.Tasks)
+		s.RestartRate = float64(s.Restarts) / float64(s.Tasks)
+		sort.SliceStable(s.ErrorSignatures, func(i, j int) bool {
+			return s.ErrorSignatures[i].Tasks > s.ErrorSignatures[j].Tasks
+		})
+		if s.Agent != UnknownAgent {
+			totalTasks += s.Tasks
+			totalFailed += s.Failed
+		}
+		summaries = append(summaries, *s)
+	}
+	for i := range summaries {
+		s := &summaries[i]
+		if s.Agent == UnknownAgent || s.Failed < outlierMinFailures || totalTasks == s.Tasks {
+			continue
+		}
+		restRate := float64(totalFailed-s.Failed) / float64(totalTasks-s.Tasks)
+		s.Outlier = s.FailureRate >= outlierFactor*restRate
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].FailureRate != summaries[j].FailureRate {
+			return summaries[i].FailureRate > summaries[j].FailureRate
+		}
+		return summaries[i].Agent < summaries[j].Agent
+	})
+	return summaries, nil
+}
+
+// WriteAgents prints the agent statistics in the text or json format. Outliers are marked with "<<".
+// Only top error signatures per agent are printed in the text format, if top is 0, all of them are printed.
+func WriteAgents(w io.Writer, summaries []AgentSummary, format string, top int) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(summaries)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "AGENT\tTASKS\tRUNNING\tFAILED\tKILLED\tRESTARTS\tFAILURE RATE\tRESTART RATE\t")
+	for _, s := range summaries {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%.0f%%\t%.0f%%\t%v\n", s.Agent, s.Tasks, s.Running,
+			s.Failed, s.Killed, s.Restarts, s.FailureRate*100, s.RestartRate*100, attentionMark(s.Outlier))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, s := range summaries {
+		if len(s.ErrorSignatures) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\nError signatures on %v:\n", s.Agent)
+		signatures := s.ErrorSignatures
+		if top > 0 && len(signatures) > top {
+			signatures = signatures[:top]
+		}
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  TASKS\tSIGNATURE")
+		for _, e := range signatures {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\n", e.Tasks, e.Template)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarizeAgents(t *testing.T) {
+	dirNames := []string{
+		"starting_20200416T110000-failed_20200416T110500__kafka-0-broker__01",
+		"starting_20200416T111000-failed_20200416T111500__kafka-0-broker__02",
+		"starting_20200416T112000-running_20200416T112100__kafka-0-broker__03",
+		"starting_20200416T110000-running_20200416T110100__kafka-1-broker__04",
+		"starting_20200416T110000-running_20200416T110100__kafka-2-broker__05",
+		"starting_20200416T110000-failed_20200416T110100__kafka-3-broker__06",
+		"starting_20200416T110000-running_20200416T110100__kafka-4-broker__07",
+		"starting_20200416T110000-running_20200416T110100__kafka-5-broker__08",
+	}
+	agents := []string{"bad", "bad", "good", "good", "good", "good", "good", ""}
+	tasks := make([]Task, 0, len(dirNames))
+	for i, dirName := range dirNames {
+		task, err := parseTaskDirName(dirName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if agents[i] != "" {
+			task.Mesos = &MesosTask{AgentHost: agents[i]}
+		}
+		tasks = append(tasks, task)
+	}
+	summaries, err := SummarizeAgents(tasks, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []AgentSummary{
+		{Agent: "bad", Tasks: 2, Failed: 2, Restarts: 1, FailureRate: 1, RestartRate: 0.5, Outlier: true},
+		{Agent: "good", Tasks: 5, Running: 4, Failed: 1, Restarts: 1, FailureRate: 0.2, RestartRate: 0.2},
+		{Agent: UnknownAgent, Tasks: 1, Running: 1},
+	}
+	if len(summaries) != len(want) {
+		t.Fatalf("SummarizeAgents() returned %v agents, want %v", len(summaries), len(want))
+	}
+	for i, w := range want {
+		s := summaries[i]
+		w.ErrorSignatures = []AgentErrorSignature{}
+		if !reflect.DeepEqual(s, w) {
+			t.Errorf("SummarizeAgents()[%v] = %+v, want %+v", i, s, w)
+		}
+	}
+}
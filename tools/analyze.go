@@ -0,0 +1,216 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Severities of the issues found by analyzers, the most severe first.
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+var severityRanks = map[string]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+// ParseSeverity checks that the severity is known.
+func ParseSeverity(s string) (string, error) {
+	s = strings.ToLower(s)
+	if _, ok := severityRanks[s]; !ok {
+		return "", fmt.Errorf("unknown severity %q, known severities: %v, %v, %v",
+			s, SeverityCritical, SeverityWarning, SeverityInfo)
+	}
+	return s, nil
+}
+
+// Bundle is the parsed bundle given to analyzers.
+type Bundle struct {
+	Path  string
+	Tasks []Task
+}
+
+// OpenLog returns a reader of all the rotations of the task log stream, see OpenTaskLog.
+func (b Bundle) OpenLog(task Task, stream string) (io.ReadCloser, error) {
+	return OpenTaskLog(task, stream)
+}
+
+// ReadFile reads a metadata file of the bundle, e.g., "service.json". The path is relative to the bundle.
+func (b Bundle) ReadFile(rel string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join(b.Path, rel))
+}
+
+// Service returns the service metadata, see ReadServiceInfo.
+func (b Bundle) Service() (ServiceInfo, error) {
+	return ReadServiceInfo(b.Path)
+}
+
+// Plans returns the plans of the scheduler, see FindPlans.
+func (b Bundle) Plans() ([]Plan, error) {
+	return FindPlans(b.Path)
+}
+
+// Issue is a problem found by an analyzer.
+type Issue struct {
+	Analyzer string `json:"analyzer"`
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+	// Tasks are the directory names of the affected tasks.
+	Tasks []string `json:"tasks"`
+	// Evidence are the log lines, statuses and other facts which show the problem.
+	Evidence    []string `json:"evidence"`
+	Remediation string   `json:"remediation"`
+}
+
+// Analyzer checks the bundle for a kind of problems. Analyzers are registered with RegisterAnalyzer.
+type Analyzer interface {
+	// Name is a short unique name, e.g., "failed-tasks".
+	Name() string
+	Description() string
+	// Analyze returns the issues found in the bundle. The Analyzer field of the issues is set by RunAnalyzers.
+	Analyze(b Bundle) ([]Issue, error)
+}
+
+var analyzers = map[string]Analyzer{}
+
+// RegisterAnalyzer makes the analyzer available to the analyze command. It returns an error if there is
+// an analyzer with the same name already.
+func RegisterAnalyzer(a Analyzer) error {
+	if _, ok := analyzers[a.Name()]; ok {
+		return fmt.Errorf("duplicate analyzer %q", a.Name())
+	}
+	analyzers[a.Name()] = a
+	return nil
+}
+
+// Analyzers returns the registered analyzers ordered by name.
+func Analyzers() []Analyzer {
+	result := make([]Analyzer, 0, len(analyzers))
+	for _, a := range analyzers {
+		result = append(result, a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result
+}
+
+// FindAnalyzer returns the registered analyzer by name.
+func FindAnalyzer(name string) (Analyzer, error) {
+	a, ok := analyzers[name]
+	if !ok {
+		names := make([]string, 0, len(analyzers))
+		for _, a := range Analyzers() {
+			names = append(names, a.Name())
+		}
+		return nil, fmt.Errorf("unknown analyzer %q, known analyzers: %v", name, strings.Join(names, ", "))
+	}
+	return a, nil
+}
+
+// AnalyzerError is an error of an analyzer which didn't stop the other analyzers.
+type AnalyzerError struct {
+	Analyzer string `json:"analyzer"`
+	Error    string `json:"error"`
+}
+
+// Analysis is the result of RunAnalyzers.
+type Analysis struct {
+	Issues []Issue         `json:"issues"`
+	Errors []AnalyzerError `json:"errors"`
+}
+
+// RunAnalyzers runs the analyzers and returns the issues ranked by severity. Errors of an analyzer
+// are recorded and don't stop the other analyzers.
+func RunAnalyzers(b Bundle, analyzers []Analyzer) Analysis {
+	result := Analysis{Issues: make([]Issue, 0), Errors: make([]AnalyzerError, 0)}
+	for _, a := range analyzers {
+		issues, err := a.Analyze(b)
+		if err != nil {
+			result.Errors = append(result.Errors, AnalyzerError{a.Name(), err.Error()})
+			continue
+		}
+		for _, issue := range issues {
+			issue.Analyzer = a.Name()
+			// Unknown severities would rank as critical, so such issues are reported as analyzer errors.
+			severity, err := ParseSeverity(issue.Severity)
+			if err != nil {
+				result.Errors = append(result.Errors, AnalyzerError{a.Name(), fmt.Sprintf("issue %q: %v", issue.Title, err)})
+				continue
+			}
+			issue.Severity = severity
+			if issue.Tasks == nil {
+				issue.Tasks = []string{}
+			}
+			if issue.Evidence == nil {
+				issue.Evidence = []string{}
+			}
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+	sort.SliceStable(result.Issues, func(i, j int) bool {
+		a, b := result.Issues[i], result.Issues[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] < severityRanks[b.Severity]
+		}
+		return a.Analyzer < b.Analyzer
+	})
+	return result
+}
+
+// FilterSeverity returns the issues at least as severe as the given severity.
+func (a Analysis) FilterSeverity(severity string) Analysis {
+	filtered := Analysis{Issues: make([]Issue, 0), Errors: a.Errors}
+	for _, issue := range a.Issues {
+		if severityRanks[issue.Severity] <= severityRanks[severity] {
+			filtered.Issues = append(filtered.Issues, issue)
+		}
+	}
+	return filtered
+}
+
+// WriteAnalysis prints the issues in the text or json format.
+func WriteAnalysis(w io.Writer, a Analysis, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(a)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	if len(a.Issues) == 0 {
+		_, _ = fmt.Fprintln(w, "No issues found.")
+	}
+	for i, issue := range a.Issues {
+		if i != 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "%v [%v] %v\n", strings.ToUpper(issue.Severity), issue.Analyzer, issue.Title)
+		if len(issue.Tasks) != 0 {
+			_, _ = fmt.Fprintln(w, "  Tasks:")
+			for _, t := range issue.Tasks {
+				_, _ = fmt.Fprintf(w, "    %v\n", t)
+			}
+		}
+		if len(issue.Evidence) != 0 {
+			_, _ = fmt.Fprintln(w, "  Evidence:")
+			for _, e := range issue.Evidence {
+				_, _ = fmt.Fprintf(w, "    %v\n", e)
+			}
+		}
+		if issue.Remediation != "" {
+			_, _ = fmt.Fprintf(w, "  Remediation: %v\n", issue.Remediation)
+		}
+	}
+	for _, e := range a.Errors {
+		_, _ = fmt.Fprintf(w, "\nAnalyzer %v failed: %v\n", e.Analyzer, e.Error)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunAnalyzers(t *testing.T) {
+	analyzers := []Analyzer{
+		analyzerFunc{"info", "", func(Bundle) ([]Issue, error) {
+			return []Issue{{Severity: SeverityInfo, Title: "i"}}, nil
+		}},
+		analyzerFunc{"broken", "", func(Bundle) ([]Issue, error) {
+			return nil, errors.New("boom")
+		}},
+		analyzerFunc{"mixed", "", func(Bundle) ([]Issue, error) {
+			return []Issue{{Severity: SeverityWarning, Title: "w"}, {Severity: SeverityCritical, Title: "c"}}, nil
+		}},
+		analyzerFunc{"custom", "", func(Bundle) ([]Issue, error) {
+			return []Issue{{Severity: "Warning", Title: "w"}, {Title: "empty"}, {Severity: "urgent", Title: "u"}}, nil
+		}},
+	}
+	a := RunAnalyzers(Bundle{}, analyzers)
+	titles := make([]string, 0)
+	for _, issue := range a.Issues {
+		titles = append(titles, issue.Analyzer+":"+issue.Title)
+	}
+	if want := []string{"mixed:c", "custom:w", "mixed:w", "info:i"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("RunAnalyzers() issues = %v, want %v", titles, want)
+	}
+	want := []AnalyzerError{
+		{"broken", "boom"},
+		{"custom", `issue "empty": unknown severity "", known severities: critical, warning, info`},
+		{"custom", `issue "u": unknown severity "urgent", known severities: critical, warning, info`},
+	}
+	if !reflect.DeepEqual(a.Errors, want) {
+		t.Errorf("RunAnalyzers() errors = %v, want %v", a.Errors, want)
+	}
+	if got := len(a.FilterSeverity(SeverityWarning).Issues); got != 3 {
+		t.Errorf("FilterSeverity(%v) returned %v issues, want 3", SeverityWarning, got)
+	}
+}
+
+func Test_analyzeRestarts(t *testing.T) {
+	tasks := make([]Task, 0)
+	for _, dirName := range []string{
+		"starting_20200416T110000-failed_20200416T110500__kafka-0-broker__01",
+		"starting_20200416T111000-failed_20200416T111500__kafka-0-broker__02",
+		"starting_20200416T112000-failed_20200416T112500__kafka-0-broker__03",
+		"starting_20200416T113000-running_20200416T113100__kafka-0-broker__04",
+		"starting_20200416T110000-failed_20200416T110500__kafka-1-broker__05",
+		"starting_20200416T111000-running_20200416T111100__kafka-1-broker__06",
+	} {
+		task, err := parseTaskDirName(dirName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+	issues, err := analyzeRestarts(Bundle{Tasks: tasks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || issues[0].Title != "kafka-0-broker was restarted 3 times" || len(issues[0].Tasks) != 4 {
+		t.Errorf("analyzeRestarts() = %+v, want one issue about kafka-0-broker", issues)
+	}
+}
+
+func Test_analyzeAgentOutliers(t *testing.T) {
+	tasks := make([]Task, 0)
+	for i, dirName := range []string{
+		"starting_20200416T110000-failed_20200416T110500__kafka-0-broker__01",
+		"starting_20200416T111000-failed_20200416T111500__kafka-0-broker__02",
+		"starting_20200416T110000-running_20200416T110100__kafka-1-broker__03",
+		"starting_20200416T110000-running_20200416T110100__kafka-2-broker__04",
+		"starting_20200416T110000-running_20200416T110100__kafka-3-broker__05",
+		"starting_20200416T110000-running_20200416T110100__kafka-4-broker__06",
+	} {
+		task, err := parseTaskDirName(dirName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The hostname of the failing agent is unknown, so its tasks are grouped by the agent ID.
+		task.Mesos = &MesosTask{AgentID: "S1"}
+		if i >= 2 {
+			task.Mesos = &MesosTask{AgentID: "S2", AgentHost: "10.0.1.12"}
+		}
+		tasks = append(tasks, task)
+	}
+	issues, err := analyzeAgentOutliers(Bundle{Tasks: tasks})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || len(issues[0].Tasks) != 2 {
+		t.Errorf("analyzeAgentOutliers() = %+v, want one issue with the 2 failed tasks of agent S1", issues)
+	}
+}
@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Restarts of a task name from which the restarts analyzer reports it.
+const restartsThreshold = 3
+
+// evidenceLines is the number of log lines the analyzers quote as evidence.
+const evidenceLines = 3
+
+// analyzerFunc is an Analyzer made of a function.
+type analyzerFunc struct {
+	name        string
+	description string
+	analyze     func(b Bundle) ([]Issue, error)
+}
+
+func (a analyzerFunc) Name() string                      { return a.name }
+func (a analyzerFunc) Description() string               { return a.description }
+func (a analyzerFunc) Analyze(b Bundle) ([]Issue, error) { return a.analyze(b) }
+
+func init() {
+	for _, a := range []Analyzer{
+		analyzerFunc{"failed-tasks", "pod instances whose latest task failed", analyzeFailedTasks},
+		analyzerFunc{"restarts", fmt.Sprintf("tasks restarted at least %v times", restartsThreshold), analyzeRestarts},
+		analyzerFunc{"missing-logs", "tasks without log files", analyzeMissingLogs},
+		analyzerFunc{"plans", "plans which are stuck or failed", analyzePlans},
+		analyzerFunc{"agent-outliers", "agents with much more failed tasks than the others", analyzeAgentOutliers},
+		analyzerFunc{"bundle-completeness", "missing or unreadable critical parts of the bundle", analyzeCompleteness},
+	} {
+		if err := RegisterAnalyzer(a); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func analyzeFailedTasks(b Bundle) ([]Issue, error) {
+	issues := make([]Issue, 0)
+	for _, t := range LatestRunPerInstance(b.Tasks) {
+		if t.State() != StateFailed {
+			continue
+		}
+		evidence := make([]string, 0)
+		if m := t.mesos(); m.Reason != "" || m.Message != "" {
+			evidence = append(evidence, fmt.Sprintf("Mesos status: %v %v", m.Reason, m.Message))
+		}
+		lines, err := lastErrorLines(b, t, evidenceLines)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, Issue{
+			Severity: SeverityCritical,
+			Title:    fmt.Sprintf("The latest run of %v failed at %v", t.Name, printTime(t.Failed)),
+			Tasks:    []string{t.DirName},
+			Evidence: append(evidence, lines...),
+			Remediation: fmt.Sprintf("Read the task logs with \"sbun logs %v\" and fix the cause "+
+				"before restarting or replacing the pod instance.", shortID(t.ID)),
+		})
+	}
+	return issues, nil
+}
+
+func analyzeRestarts(b Bundle) ([]Issue, error) {
+	runs := make(map[string][]Task)
+	for _, t := range b.Tasks {
+		runs[t.Name] = append(runs[t.Name], t)
+	}
+	names := make([]string, 0)
+	for name, n := range CountRestarts(b.Tasks) {
+		if n >= restartsThreshold {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	issues := make([]Issue, 0, len(names))
+	for _, name := range names {
+		tasks := runs[name]
+		sort.Slice(tasks, func(i, j int) bool {
+			return tasks[i].Started().Before(tasks[j].Started())
+		})
+		issue := Issue{
+			Severity: SeverityWarning,
+			Title:    fmt.Sprintf("%v was restarted %v times", name, len(tasks)-1),
+			Remediation: "Compare the logs of the runs, e.g., with \"sbun summarize-logs\", to find out " +
+				"why the task keeps restarting.",
+		}
+		for _, t := range tasks {
+			issue.Tasks = append(issue.Tasks, t.DirName)
+			issue.Evidence = append(issue.Evidence, fmt.Sprintf("%v %v %v", printTime(t.Started()), t.State(), shortID(t.ID)))
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func analyzeMissingLogs(b Bundle) ([]Issue, error) {
+	issue := Issue{
+		Severity: SeverityInfo,
+		Remediation: "Logs of tasks on unreachable agents are not collected. If the logs are needed, collect " +
+			"them from the agents or configure the log streams, see \"sbun config\".",
+	}
+	for _, t := range b.Tasks {
+		if !t.HasLogs {
+			issue.Tasks = append(issue.Tasks, t.DirName)
+		}
+	}
+	if len(issue.Tasks) == 0 {
+		return nil, nil
+	}
+	issue.Title = fmt.Sprintf("%v of %v tasks have no logs", len(issue.Tasks), len(b.Tasks))
+	return []Issue{issue}, nil
+}
+
+func analyzePlans(b Bundle) ([]Issue, error) {
+	plans, err := b.Plans()
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0)
+	for _, p := range plans {
+		if !p.NeedsAttention() {
+			continue
+		}
+		issue := Issue{
+			Severity: SeverityWarning,
+			Title:    fmt.Sprintf("Plan %v is %v", p.Name, p.Status),
+			Evidence: append([]string{}, p.Errors...),
+			Remediation: "Check the scheduler logs with \"sbun scheduler\" for the offers and plan steps " +
+				"which cannot progress.",
+		}
+		if len(p.Errors) != 0 || strings.ToUpper(p.Status) == "ERROR" {
+			issue.Severity = SeverityCritical
+		}
+		for _, phase := range p.Phases {
+			for _, step := range phase.Steps {
+				if step.NeedsAttention() {
+					issue.Evidence = append(issue.Evidence, strings.TrimSpace(fmt.Sprintf("%v/%v: %v %v",
+						phase.Name, step.Name, step.Status, step.Message)))
+				}
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func analyzeAgentOutliers(b Bundle) ([]Issue, error) {
+	summaries, err := SummarizeAgents(b.Tasks, false)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]Issue, 0)
+	for _, s := range summaries {
+		if !s.Outlier {
+			continue
+		}
+		issue := Issue{
+			Severity: SeverityWarning,
+			Title: fmt.Sprintf("Agent %v has %v failed tasks of %v, failure rate %.0f%%", s.Agent, s.Failed,
+				s.Tasks, s.FailureRate*100),
+			Remediation: "Check the agent health: disk space, network and the Mesos agent logs. " +
+				"Consider draining the agent.",
+		}
+		for _, t := range b.Tasks {
+			if agentKey(t) == s.Agent && t.State() == StateFailed {
+				issue.Tasks = append(issue.Tasks, t.DirName)
+			}
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+func analyzeCompleteness(b Bundle) ([]Issue, error) {
+	c, err := CheckBundle(b.Path)
+	if err != nil {
+		return nil, err
+	}
+	if c.Complete() {
+		return nil, nil
+	}
+	issue := Issue{
+		Severity:    SeverityCritical,
+		Title:       "The bundle is incomplete",
+		Remediation: "Collect the bundle again; the results of the other analyzers can be incomplete.",
+	}
+	for _, p := range c.Parts {
+		if p.Critical && p.Status != PartOK {
+			issue.Evidence = append(issue.Evidence, fmt.Sprintf("%v: %v", p.Name, p.Status))
+			issue.Evidence = append(issue.Evidence, p.Details...)
+		}
+	}
+	return []Issue{issue}, nil
+}
+
+// lastErrorLines returns the last n error lines of the task logs prefixed with the stream name.
+func lastErrorLines(b Bundle, t Task, n int) ([]string, error) {
+	lines := make([]string, 0)
+	for _, stream := range LogStreamNames() {
+		r, err := b.OpenLog(t, stream)
+		if err != nil {
+			return nil, err
+		}
+		err = forEachLineErr(r, func(line string) error {
+			if _, message, _ := ParseLineTime(line); IsErrorLine(message) {
+				lines = append(lines, stream+": "+line)
+				if len(lines) > n {
+					lines = lines[1:]
+				}
+			}
+			return nil
+		})
+		closeCloser(r)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read %v of the task %v: %v", stream, t.DirName, err)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// IsBundle reports whether the directory is a service diagnostics bundle, i.e., it has the tasks directory.
+func IsBundle(path string) bool {
+	return dirExists(filepath.Join(path, DirNameTasks))
+}
+
+// FindBundles returns the bundles the path refers to. The path is a bundle, a glob pattern matching
+// bundles, e.g., "customer/bundle-*", or a directory with bundles. Bundles are ordered by path.
+func FindBundles(path string) ([]string, error) {
+	if IsBundle(path) {
+		return []string{path}, nil
+	}
+	var candidates []string
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bundle path pattern %q: %v", path, err)
+		}
+		candidates = matches
+	} else {
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot list bundles in %v: %v", path, err)
+		}
+		// Links to bundles are followed, so entries are not filtered by IsDir.
+		for _, info := range infos {
+			candidates = append(candidates, filepath.Join(path, info.Name()))
+		}
+	}
+	bundles := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if IsBundle(c) {
+			bundles = append(bundles, c)
+		}
+	}
+	if len(bundles) == 0 {
+		return nil, fmt.Errorf("no bundles found in %v: a bundle is a directory with the \"%v\" directory",
+			path, DirNameTasks)
+	}
+	sort.Strings(bundles)
+	return bundles, nil
+}
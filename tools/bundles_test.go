@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFindBundles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-bundles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for _, p := range []string{"b-2/tasks", "b-1/tasks", "other/tasks", "not-bundle"} {
+		if err := os.MkdirAll(filepath.Join(dir, p), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{filepath.Join(dir, "b-1"), []string{"b-1"}, false},
+		{filepath.Join(dir, "b-*"), []string{"b-1", "b-2"}, false},
+		{dir, []string{"b-1", "b-2", "other"}, false},
+		{filepath.Join(dir, "not-bundle"), nil, true},
+		{filepath.Join(dir, "[-"), nil, true},
+	}
+	for _, tt := range tests {
+		got, err := FindBundles(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("FindBundles(%v) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		var want []string
+		for _, w := range tt.want {
+			want = append(want, filepath.Join(dir, w))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("FindBundles(%v) = %v, want %v", tt.path, got, want)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package tools
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Types of expectations.
+const (
+	// ExpectRunning expects every pod instance of the selected tasks to have a running task.
+	ExpectRunning = "running"
+	// ExpectMaxRestarts expects every selected task name to be restarted at most Max times.
+	ExpectMaxRestarts = "max-restarts"
+	// ExpectMaxTasks expects at most Max selected tasks, e.g., no tasks with the filter "state=failed".
+	ExpectMaxTasks = "max-tasks"
+	// ExpectMaxIssues expects at most Max analyzer issues at least as severe as Severity.
+	ExpectMaxIssues = "max-issues"
+)
+
+// Expectations is the content of an expectations file.
+type Expectations struct {
+	Expectations []Expectation `json:"expectations"`
+}
+
+// Expectation is a condition the bundle must satisfy to pass the check.
+type Expectation struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Filter selects the tasks, see ParseTaskFilter. All tasks are selected if it is empty.
+	Filter []string `json:"filter,omitempty"`
+	Max    int      `json:"max,omitempty"`
+	// Severity and Analyzers are used by the max-issues expectations. All analyzers are run if Analyzers is empty.
+	Severity  string   `json:"severity,omitempty"`
+	Analyzers []string `json:"analyzers,omitempty"`
+
+	filter TaskFilter
+}
+
+// ExpectationResult is the result of checking an expectation.
+type ExpectationResult struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message"`
+	// Failures are the pod instances, tasks or issues which violate the expectation.
+	Failures []string `json:"failures"`
+}
+
+// ReadExpectations reads and validates the expectations file, e.g.:
+//
+//	{"expectations": [
+//	  {"name": "brokers are running", "type": "running", "filter": ["pod-type=kafka"]},
+//	  {"name": "no crash loops", "type": "max-restarts", "max": 3},
+//	  {"name": "no failed tasks", "type": "max-tasks", "filter": ["state=failed"], "max": 0},
+//	  {"name": "no critical issues", "type": "max-issues", "severity": "critical", "max": 0}
+//	]}
+func ReadExpectations(path string) (Expectations, error) {
+	e := Expectations{}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return e, fmt.Errorf("cannot read expectations file: %v", err)
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, fmt.Errorf("cannot parse expectations file %v: %v", path, err)
+	}
+	if len(e.Expectations) == 0 {
+		return e, fmt.Errorf("expectations file %v has no expectations", path)
+	}
+	for i := range e.Expectations {
+		if err := e.Expectations[i].compile(); err != nil {
+			return e, fmt.Errorf("invalid expectation %v in %v: %v", i+1, path, err)
+		}
+	}
+	return e, nil
+}
+
+func (e *Expectation) compile() error {
+	if e.Name == "" {
+		e.Name = e.Type
+	}
+	var err error
+	if e.filter, err = ParseTaskFilter(e.Filter); err != nil {
+		return err
+	}
+	if e.Max < 0 {
+		return fmt.Errorf("max must not be negative")
+	}
+	switch e.Type {
+	case ExpectRunning, ExpectMaxRestarts, ExpectMaxTasks:
+	case ExpectMaxIssues:
+		if e.Severity == "" {
+			e.Severity = SeverityCritical
+		}
+		if e.Severity, err = ParseSeverity(e.Severity); err != nil {
+			return err
+		}
+		for _, name := range e.Analyzers {
+			if _, err := FindAnalyzer(name); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown expectation type %q, known types: %v", e.Type,
+			strings.Join([]string{ExpectRunning, ExpectMaxRestarts, ExpectMaxTasks, ExpectMaxIssues}, ", "))
+	}
+	return nil
+}
+
+// CheckExpectations checks the expectations against the bundle. Analyzers are run at most once.
+func CheckExpectations(b Bundle, e Expectations) []ExpectationResult {
+	results := make([]ExpectationResult, 0, len(e.Expectations))
+	var analysis *Analysis
+	for _, exp := range e.Expectations {
+		tasks := exp.filter.Filter(b.Tasks)
+		r := ExpectationResult{Name: exp.Name, Type: exp.Type, Failures: make([]string, 0)}
+		switch exp.Type {
+		case ExpectRunning:
+			running := make(map[string]bool)
+			for _, t := range tasks {
+				running[t.PodInstance()] = running[t.PodInstance()] || t.State() == StateRunning
+			}
+			for pod, ok := range running {
+				if !ok {
+					r.Failures = append(r.Failures, pod)
+				}
+			}
+			r.Message = fmt.Sprintf("%v of %v pod instances have no running task", len(r.Failures), len(running))
+			if len(running) == 0 {
+				r.Failures = append(r.Failures, "no tasks match the filter")
+				r.Message = "no pod instances found"
+			}
+		case ExpectMaxRestarts:
+			for name, n := range CountRestarts(tasks) {
+				if n > exp.Max {
+					r.Failures = append(r.Failures, fmt.Sprintf("%v: %v restarts", name, n))
+				}
+			}
+			r.Message = fmt.Sprintf("%v tasks restarted more than %v times", len(r.Failures), exp.Max)
+		case ExpectMaxTasks:
+			if len(tasks) > exp.Max {
+				for _, t := range tasks {
+					r.Failures = append(r.Failures, t.DirName)
+				}
+			}
+			r.Message = fmt.Sprintf("%v tasks match, at most %v expected", len(tasks), exp.Max)
+		case ExpectMaxIssues:
+			if analysis == nil {
+				a := RunAnalyzers(b, Analyzers())
+				analysis = &a
+			}
+			matching := make([]string, 0)
+			for _, issue := range analysis.FilterSeverity(exp.Severity).Issues {
+				if len(exp.Analyzers) == 0 || containsName(exp.Analyzers, issue.Analyzer) {
+					matching = append(matching, fmt.Sprintf("%v [%v] %v",
+						strings.ToUpper(issue.Severity), issue.Analyzer, issue.Title))
+				}
+			}
+			if len(matching) > exp.Max {
+				r.Failures = matching
+			}
+			// The expectation cannot pass if the analyzers didn't run.
+			for _, e := range analysis.Errors {
+				if len(exp.Analyzers) == 0 || containsName(exp.Analyzers, e.Analyzer) {
+					r.Failures = append(r.Failures, fmt.Sprintf("analyzer %v failed: %v", e.Analyzer, e.Error))
+				}
+			}
+			r.Message = fmt.Sprintf("%v issues at least %v, at most %v expected", len(matching), exp.Severity, exp.Max)
+		}
+		sort.Strings(r.Failures)
+		r.Passed = len(r.Failures) == 0
+		results = append(results, r)
+	}
+	return results
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpectationsPassed reports whether all the expectations passed.
+func ExpectationsPassed(results []ExpectationResult) bool {
+	for _, r := range results {
+		if !r.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteExpectationResults prints the results in the text, json or junit format. The JUnit XML has
+// a test case per expectation in a test suite named after the bundle.
+func WriteExpectationResults(w io.Writer, bundlePath string, results []ExpectationResult, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(results)
+	case "junit":
+		suite := junitTestSuite{Name: bundlePath, Tests: len(results)}
+		for _, r := range results {
+			tc := junitTestCase{Name: r.Name, ClassName: "sbun." + r.Type}
+			if !r.Passed {
+				suite.Failures++
+				tc.Failure = &junitFailure{Message: r.Message, Text: strings.Join(r.Failures, "\n")}
+			}
+			suite.TestCases = append(suite.TestCases, tc)
+		}
+		_, _ = io.WriteString(w, xml.Header)
+		encoder := xml.NewEncoder(w)
+		encoder.Indent("", "  ")
+		if err := encoder.Encode(suite); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	passed := 0
+	for _, r := range results {
+		status := "FAIL"
+		if r.Passed {
+			status = "PASS"
+			passed++
+		}
+		_, _ = fmt.Fprintf(w, "%v %v: %v\n", status, r.Name, r.Message)
+		if !r.Passed {
+			for _, f := range r.Failures {
+				_, _ = fmt.Fprintf(w, "  %v\n", f)
+			}
+		}
+	}
+	_, _ = fmt.Fprintf(w, "\n%v of %v expectations passed.\n", passed, len(results))
+	return nil
+}
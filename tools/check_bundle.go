@@ -0,0 +1,256 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+)
+
+// Statuses of the bundle parts.
+const (
+	PartOK         = "ok"
+	PartMissing    = "missing"
+	PartEmpty      = "empty"
+	PartUnreadable = "unreadable"
+)
+
+// BundlePart is a part of the expected layout of a service diagnostics bundle.
+type BundlePart struct {
+	Name string `json:"name"`
+	// Critical parts are needed for most of the analysis.
+	Critical bool   `json:"critical"`
+	Status   string `json:"status"`
+	// Paths are the files and directories of the part relative to the bundle.
+	Paths   []string `json:"paths"`
+	Details []string `json:"details,omitempty"`
+}
+
+// BundleCheck is the result of CheckBundle.
+type BundleCheck struct {
+	Bundle string       `json:"bundle"`
+	Parts  []BundlePart `json:"parts"`
+	// Unreadable are the files and directories which cannot be read, e.g., because of permissions.
+	Unreadable       []string `json:"unreadable"`
+	TasksWithoutLogs []string `json:"tasksWithoutLogs"`
+}
+
+// Complete reports whether all the critical parts are present and readable.
+func (c BundleCheck) Complete() bool {
+	for _, p := range c.Parts {
+		if p.Critical && p.Status != PartOK {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedPart describes a part of the bundle layout. The part consists of the files for which match
+// returns true and the directories of the tasks returned by tasks. Files in the tasks directory are never matched.
+type expectedPart struct {
+	name     string
+	critical bool
+	match    func(rel string) bool
+	tasks    func(tasks []Task) []Task
+}
+
+var expectedParts = []expectedPart{
+	{"service configuration", true, isServiceConfigFile, nil},
+	{"scheduler files", false, func(rel string) bool {
+		return strings.HasPrefix(rel, DirNameScheduler+string(filepath.Separator))
+	}, SchedulerTasks},
+	{"Mesos state", false, func(rel string) bool {
+		return isMesosStateFile(filepath.Base(rel))
+	}, nil},
+	{"plans", false, isPlanFile, nil},
+}
+
+func isServiceConfigFile(rel string) bool {
+	name := strings.ToLower(filepath.Base(rel))
+	return rel == "service.json" || filepath.Ext(name) == ".json" &&
+		(strings.Contains(name, "config") || strings.Contains(name, "options"))
+}
+
+// CheckBundle compares the bundle with the expected layout of a service diagnostics bundle and reports missing,
+// empty and unreadable parts and tasks without logs.
+func CheckBundle(bundlePath string) (BundleCheck, error) {
+	c := BundleCheck{Bundle: bundlePath, Unreadable: make([]string, 0), TasksWithoutLogs: make([]string, 0)}
+	if !dirExists(bundlePath) {
+		return c, fmt.Errorf("bundle directory %v doesn't exist", bundlePath)
+	}
+	files := make([]string, 0)
+	err := filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(bundlePath, path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			c.Unreadable = append(c.Unreadable, fmt.Sprintf("%v: %v", rel, err))
+			return nil
+		}
+		if info.IsDir() {
+			if rel == DirNameTasks {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, ViewMarkerFileName)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+	tasks, tasksPart := checkTasks(bundlePath)
+	c.Parts = append(c.Parts, tasksPart)
+	for _, t := range tasks {
+		if !t.HasLogs {
+			c.TasksWithoutLogs = append(c.TasksWithoutLogs, t.DirName)
+		}
+	}
+	for _, e := range expectedParts {
+		part := BundlePart{Name: e.name, Critical: e.critical, Status: PartOK, Paths: make([]string, 0)}
+		for _, rel := range files {
+			if !e.match(rel) {
+				continue
+			}
+			part.Paths = append(part.Paths, rel)
+			if status, details := checkFile(filepath.Join(bundlePath, rel)); status != PartOK {
+				part.Details = append(part.Details, fmt.Sprintf("%v: %v", rel, details))
+				part.Status = worseStatus(part.Status, status)
+			}
+		}
+		if e.tasks != nil {
+			for _, t := range e.tasks(tasks) {
+				part.Paths = append(part.Paths, filepath.Join(DirNameTasks, t.DirName))
+			}
+		}
+		if len(part.Paths) == 0 {
+			part.Status = PartMissing
+		}
+		c.Parts = append(c.Parts, part)
+	}
+	return c, nil
+}
+
+func checkTasks(bundlePath string) ([]Task, BundlePart) {
+	part := BundlePart{Name: "tasks directory", Critical: true, Status: PartOK, Paths: []string{}}
+	infos, err := ioutil.ReadDir(filepath.Join(bundlePath, DirNameTasks))
+	switch {
+	case os.IsNotExist(err):
+		part.Status = PartMissing
+		return nil, part
+	case err != nil:
+		part.Status, part.Details = PartUnreadable, []string{err.Error()}
+		return nil, part
+	case len(infos) == 0:
+		part.Status = PartEmpty
+		return nil, part
+	}
+	tasks, err := FindTasks(bundlePath)
+	if err != nil {
+		part.Status, part.Details = PartEmpty, []string{err.Error()}
+		return nil, part
+	}
+	part.Paths = make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		part.Paths = append(part.Paths, filepath.Join(DirNameTasks, t.DirName))
+	}
+	return tasks, part
+}
+
+// checkFile returns the status of the file and the details if the file is not ok. JSON files which cannot
+// be parsed, e.g., because the collection was interrupted, are unreadable.
+func checkFile(path string) (string, string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return PartUnreadable, err.Error()
+	}
+	if info.Size() == 0 {
+		return PartEmpty, "empty file"
+	}
+	if filepath.Ext(path) != ".json" {
+		f, err := os.Open(path)
+		if err != nil {
+			return PartUnreadable, err.Error()
+		}
+		defer closeCloser(f)
+		if _, err := f.Read(make([]byte, 1)); err != nil {
+			return PartUnreadable, err.Error()
+		}
+		return PartOK, ""
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return PartUnreadable, err.Error()
+	}
+	if !json.Valid(data) {
+		return PartUnreadable, "invalid or truncated JSON"
+	}
+	return PartOK, ""
+}
+
+// worseStatus returns the status which means more problems.
+func worseStatus(a string, b string) string {
+	rank := map[string]int{PartOK: 0, PartEmpty: 1, PartUnreadable: 2, PartMissing: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// WriteBundleCheck prints the check result in the text or json format. Critical parts which are not ok
+// are marked with "<<".
+func WriteBundleCheck(w io.Writer, c BundleCheck, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(c)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "PART\tCRITICAL\tSTATUS\tFILES\t")
+	for _, p := range c.Parts {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\n", p.Name, p.Critical, p.Status, len(p.Paths),
+			attentionMark(p.Critical && p.Status != PartOK))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, p := range c.Parts {
+		if len(p.Details) == 0 {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\nProblems with %v:\n", p.Name)
+		for _, d := range p.Details {
+			_, _ = fmt.Fprintf(w, "  %v\n", d)
+		}
+	}
+	if len(c.Unreadable) != 0 {
+		_, _ = fmt.Fprintf(w, "\nUnreadable files and directories: %v\n", len(c.Unreadable))
+		for _, u := range c.Unreadable {
+			_, _ = fmt.Fprintf(w, "  %v\n", u)
+		}
+	}
+	if len(c.TasksWithoutLogs) != 0 {
+		_, _ = fmt.Fprintf(w, "\nTasks without logs: %v\n", len(c.TasksWithoutLogs))
+		for _, t := range c.TasksWithoutLogs {
+			_, _ = fmt.Fprintf(w, "  %v\n", t)
+		}
+	}
+	if c.Complete() {
+		_, _ = fmt.Fprintln(w, "\nAll the critical parts are present.")
+	} else {
+		_, _ = fmt.Fprintln(w, "\nThe bundle is incomplete: critical parts are missing or unreadable.")
+	}
+	return nil
+}
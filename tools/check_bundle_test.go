@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for _, d := range []string{"tasks/starting_20200416T110000__kafka-0-broker__01", "plans"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0777); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		"plans/deploy.json":  `{"phases": [`,
+		"mesos_state.json":   "",
+		"options_config.txt": "not JSON",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := CheckBundle(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"tasks directory":       PartOK,
+		"service configuration": PartMissing,
+		"scheduler files":       PartMissing,
+		"Mesos state":           PartEmpty,
+		"plans":                 PartUnreadable,
+	}
+	for _, p := range c.Parts {
+		if p.Status != want[p.Name] {
+			t.Errorf("part %q status = %q, want %q", p.Name, p.Status, want[p.Name])
+		}
+	}
+	if c.Complete() {
+		t.Error("Complete() = true, want false")
+	}
+	if len(c.TasksWithoutLogs) != 1 {
+		t.Errorf("TasksWithoutLogs = %v, want 1 task", c.TasksWithoutLogs)
+	}
+}
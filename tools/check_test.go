@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCheckExpectations(t *testing.T) {
+	tasks := make([]Task, 0)
+	for _, dirName := range []string{
+		"starting_20200416T110000-failed_20200416T110500__kafka-0-broker__01",
+		"starting_20200416T111000-running_20200416T111100__kafka-0-broker__02",
+		"starting_20200416T110000-failed_20200416T110500__kafka-1-broker__03",
+		"starting_20200416T110000-running_20200416T110100__zookeeper-0-server__04",
+	} {
+		task, err := parseTaskDirName(dirName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, task)
+	}
+	e := Expectations{Expectations: []Expectation{
+		{Name: "brokers", Type: ExpectRunning, Filter: []string{"pod-type=kafka"}},
+		{Name: "zookeeper", Type: ExpectRunning, Filter: []string{"pod-type=zookeeper"}},
+		{Name: "nothing", Type: ExpectRunning, Filter: []string{"pod-type=hdfs"}},
+		{Name: "restarts", Type: ExpectMaxRestarts, Max: 1},
+		{Name: "no restarts", Type: ExpectMaxRestarts},
+		{Name: "failed", Type: ExpectMaxTasks, Filter: []string{"state=failed"}, Max: 2},
+	}}
+	for i := range e.Expectations {
+		if err := e.Expectations[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	results := CheckExpectations(Bundle{Tasks: tasks}, e)
+	want := map[string][]string{
+		"brokers":     {"kafka-1"},
+		"zookeeper":   {},
+		"nothing":     {"no tasks match the filter"},
+		"restarts":    {},
+		"no restarts": {"kafka-0-broker: 1 restarts"},
+		"failed":      {},
+	}
+	for _, r := range results {
+		if !reflect.DeepEqual(r.Failures, want[r.Name]) || r.Passed != (len(want[r.Name]) == 0) {
+			t.Errorf("expectation %v failures = %v, passed = %v, want %v", r.Name, r.Failures, r.Passed, want[r.Name])
+		}
+	}
+	var b bytes.Buffer
+	if err := WriteExpectationResults(&b, "bundle", results, "junit"); err != nil {
+		t.Fatal(err)
+	}
+	suite := junitTestSuite{}
+	if err := xml.Unmarshal(b.Bytes(), &suite); err != nil {
+		t.Fatalf("cannot parse JUnit XML: %v\n%v", err, b.String())
+	}
+	if suite.Tests != 6 || suite.Failures != 3 {
+		t.Errorf("JUnit suite has %v tests and %v failures, want 6 and 3", suite.Tests, suite.Failures)
+	}
+}
+
+func TestReadExpectations_errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-check")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for _, content := range []string{
+		`{"expectations": []}`,
+		`{"expectations": [{"type": "unknown"}]}`,
+		`{"expectations": [{"type": "max-tasks", "filter": ["bogus"]}]}`,
+		`{"expectations": [{"type": "max-issues", "severity": "fatal"}]}`,
+		`{"expectations": [{"type": "max-issues", "analyzers": ["unknown"]}]}`,
+		`{"expectations": [{"type": "max-tasks", "max": -1}]}`,
+	} {
+		path := filepath.Join(dir, "expectations.json")
+		if err := ioutil.WriteFile(path, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadExpectations(path); err == nil {
+			t.Errorf("ReadExpectations() accepts %v", content)
+		}
+	}
+}
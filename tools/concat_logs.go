@@ -27,14 +27,9 @@ func Concat(bundlePath string, compress bool) error {
 	}
 	errs := make([]string, 0, 2)
 	for _, task := range tasks {
-		for _, dir := range []string{"", taskLogDirName, executorLogDirName} {
-			dir = filepath.Join(task.DirNameAbsolute, dir)
-			if !dirExists(dir) {
-				continue
-			}
-			if err := concat(dir, compress); err != nil {
+		for _, s := range logStreams {
+			if err := concatStream(task, s, compress); err != nil {
 				errs = append(errs, err.Error())
-				break
 			}
 		}
 	}
@@ -44,28 +39,27 @@ func Concat(bundlePath string, compress bool) error {
 	return nil
 }
 
-func concat(dir string, compress bool) error {
-	errs := make([]string, 0, 2)
-	if err := concatStdout(dir, compress); err != nil {
-		errs = append(errs, err.Error())
+func concatStream(task Task, s LogStream, compress bool) error {
+	dirs, err := s.taskDirs(task.DirNameAbsolute)
+	if err != nil {
+		return err
 	}
-	if err := concatStderr(dir, compress); err != nil {
-		errs = append(errs, err.Error())
+	errs := make([]string, 0, 2)
+	for _, dir := range dirs {
+		if err := concatInDirectory(dir, s.FileName, compress, filepath.Join(dir, s.AllFileName)); err != nil {
+			errs = append(errs, err.Error())
+		}
 	}
 	if len(errs) != 0 {
-		return fmt.Errorf("errors when concatenating stderr and stdout logs in dir %v: %v",
-			dir, strings.Join(errs, ";"))
+		return fmt.Errorf("errors when concatenating %v logs of the task %v: %v",
+			s.Name, task.DirName, strings.Join(errs, ";"))
 	}
 	return nil
 }
 
-func concatStdout(dir string, compress bool) error {
-	return concatInDirectory(dir, stdoutRegexp, compress, filepath.Join(dir, stdoutAllFileName))
-}
-
-func concatStderr(dir string, compress bool) error {
-	return concatInDirectory(dir, stderrRegexp, compress, filepath.Join(dir, stderrAllFileName))
-}
+// DamagedSuffix is appended to the names of damaged log files after concatenation, so that they are not
+// concatenated again, but can still be salvaged with other tools.
+const DamagedSuffix = ".damaged"
 
 func concatInDirectory(dir string, r *regexp.Regexp, compress bool, outName string) error {
 	infos, err := ioutil.ReadDir(dir)
@@ -83,6 +77,13 @@ func concatInDirectory(dir string, r *regexp.Regexp, compress bool, outName stri
 	if len(paths) == 0 {
 		return nil
 	}
+	// Overwriting the file from the previous run would lose the logs concatenated there.
+	for _, name := range []string{outName, strings.TrimSuffix(outName, ".gz") + ".gz"} {
+		if _, err := os.Stat(name); err == nil {
+			return fmt.Errorf("%v already exists, the files %v were not concatenated to it", name,
+				strings.Join(paths, ", "))
+		}
+	}
 	sortPathsByFileName(paths, r)
 	var out io.WriteCloser
 	if compress {
@@ -105,17 +106,34 @@ func concatInDirectory(dir string, r *regexp.Regexp, compress bool, outName stri
 	if err := concatFiles(paths, out); err != nil {
 		return fmt.Errorf("cannot concatenate files in dir %v: %v", dir, err)
 	}
-	if err := removeFiles(paths); err != nil {
-		return err
+	intact := make([]string, 0, len(paths))
+	errs := make([]string, 0)
+	for _, path := range paths {
+		if !isDamaged(path) {
+			intact = append(intact, path)
+			continue
+		}
+		if err := os.Rename(path, path+DamagedSuffix); err != nil {
+			errs = append(errs, fmt.Sprintf("cannot rename damaged file: %v", err))
+		}
+	}
+	if err := removeFiles(intact); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%v", strings.Join(errs, "; "))
 	}
 	return nil
 }
 
+// concatFiles writes the files one after another. Files which cannot be opened are skipped and recorded,
+// see LogDamages.
 func concatFiles(paths []string, out io.Writer) error {
 	for _, path := range paths {
 		r, err := fileReader(path)
 		if err != nil {
-			return fmt.Errorf("cannot open input file while concatenating: %v", err.Error())
+			recordDamage(LogDamage{Path: path, Skipped: -1, Error: err.Error()})
+			continue
 		}
 		if _, err := io.Copy(out, r); err != nil {
 			return fmt.Errorf("cannot copy bytes while concatenating: %v", err.Error())
@@ -138,83 +156,59 @@ func removeFiles(paths []string) error {
 	return nil
 }
 
+// filterPathsByFileName returns the paths with the file names matching the regexp and a rotation index.
 func filterPathsByFileName(paths []string, r *regexp.Regexp) []string {
 	expectedPaths := make([]string, 0, len(paths))
 	for _, path := range paths {
 		if !r.MatchString(filepath.Base(path)) {
 			continue
 		}
+		if _, err := fileNumber(filepath.Base(path), r); err != nil {
+			continue
+		}
 		expectedPaths = append(expectedPaths, path)
 	}
 	return expectedPaths
 }
 
+// sortPathsByFileName sorts the paths filtered with filterPathsByFileName, the oldest rotation first.
 func sortPathsByFileName(paths []string, r *regexp.Regexp) {
+	number := func(path string) int {
+		n, _ := fileNumber(filepath.Base(path), r)
+		return n
+	}
 	sort.Slice(paths, func(i, j int) bool {
-		return fileNumber(filepath.Base(paths[i]), r) > fileNumber(filepath.Base(paths[j]), r)
+		return number(paths[i]) > number(paths[j])
 	})
 }
 
-// fileNumber("stdout.1.gz") returns 1, fileNumber("stdout.gz") returns 0
-func fileNumber(fileName string, r *regexp.Regexp) int {
+// fileNumber("stdout.1.gz") returns 1, fileNumber("stdout.gz") returns 0.
+// The number is taken from the group named "rotation" or, if there is no such group, from the first group.
+// Log stream configurations are checked to capture only digits in the group, see checkRotationGroup,
+// but a group like "([0-9.]+)" can still capture "1.2", so an error is returned for such names.
+func fileNumber(fileName string, r *regexp.Regexp) (int, error) {
 	groups := r.FindStringSubmatch(fileName)
-	if len(groups) != 3 {
-		panic("expected only matching files, got " + fileName)
+	if groups == nil {
+		return 0, fmt.Errorf("file name %v doesn't match %v", fileName, r)
 	}
-	if groups[1] == "" {
-		return 0
+	group := strings.Trim(groups[rotationGroupIndex(r)], ".-_")
+	if group == "" {
+		return 0, nil
 	}
-	n, err := strconv.Atoi(groups[1][1:])
+	n, err := strconv.Atoi(group)
 	if err != nil {
-		panic("unexpected conversion error: " + groups[1] + " is not a number")
+		return 0, fmt.Errorf("rotation index %q of the file %v is not a number", group, fileName)
 	}
-	return n
+	return n, nil
 }
 
+// fileReader opens the file, decompressing it if it has the .gz extension. Damaged parts
+// of compressed files are skipped and recorded, see LogDamages.
 func fileReader(path string) (io.ReadCloser, error) {
-	r, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
 	if filepath.Ext(path) != ".gz" {
-		return r, nil
+		return os.Open(path)
 	}
-	gzr, err := gzip.NewReader(r)
-	if err != nil {
-		_ = r.Close()
-		return nil, err
-	}
-	return newReadParentCloser(gzr, r), nil
-}
-
-func newReadParentCloser(rc io.ReadCloser, parent io.Closer) io.ReadCloser {
-	return &struct {
-		io.Reader
-		io.Closer
-	}{rc, newParentCloser(rc, parent)}
-}
-
-type parentCloser struct {
-	io.Closer
-	parent io.Closer
-}
-
-func newParentCloser(c io.Closer, parent io.Closer) *parentCloser {
-	return &parentCloser{c, parent}
-}
-
-func (pc *parentCloser) Close() error {
-	errs := make([]string, 0, 2)
-	if err := pc.Closer.Close(); err != nil {
-		errs = append(errs, err.Error())
-	}
-	if err := pc.parent.Close(); err != nil {
-		errs = append(errs, "Cannot close parent: "+err.Error())
-	}
-	if len(errs) != 0 {
-		return fmt.Errorf(strings.Join(errs, ";"))
-	}
-	return nil
+	return newSalvageReader(path)
 }
 
 func closeCloser(c io.Closer) {
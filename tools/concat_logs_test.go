@@ -1,6 +1,9 @@
 package tools
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
 	"testing"
@@ -12,44 +15,67 @@ func Test_fileNumber(t *testing.T) {
 		r        *regexp.Regexp
 	}
 	tests := []struct {
-		name string
-		args args
-		want int
+		name    string
+		args    args
+		want    int
+		wantErr bool
 	}{
 		{
 			"returns 0 when there is no number and no extension",
 			args{"stdout", stdoutRegexp},
 			0,
+			false,
 		},
 		{
 			"returns a correct number when there is a number and no extension",
 			args{"stdout.1", stdoutRegexp},
 			1,
+			false,
 		},
 		{
 			"returns a correct number when there is a number > 9, and no extension",
 			args{"stdout.999", stdoutRegexp},
 			999,
+			false,
 		},
 		{
 			"returns 0 when there is no number with extension",
 			args{"stdout.gz", stdoutRegexp},
 			0,
+			false,
 		},
 		{
 			"returns a correct number when there is a number with extension",
 			args{"stdout.1.gz", stdoutRegexp},
 			1,
+			false,
 		},
 		{
 			"returns a correct number when there is a number > 9, with extension",
 			args{"stdout.999.gz", stdoutRegexp},
 			999,
+			false,
+		},
+		{
+			"returns a number from the rotation group",
+			args{"server-2020.7.log", regexp.MustCompile(`^server-(\d+)(\.(?P<rotation>\d+))?\.log$`)},
+			7,
+			false,
+		},
+		{
+			"returns an error when the rotation is not a number",
+			args{"server.1.2.log", regexp.MustCompile(`^server\.([0-9.]+)\.log$`)},
+			0,
+			true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := fileNumber(tt.args.fileName, tt.args.r); got != tt.want {
+			got, err := fileNumber(tt.args.fileName, tt.args.r)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fileNumber() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
 				t.Errorf("fileNumber() = %v, want %v", got, tt.want)
 			}
 		})
@@ -83,6 +109,14 @@ func Test_filterLogFilePaths(t *testing.T) {
 			},
 			[]string{"/path/to/logs/stdout.gz", "stdout", "stdout.2.gz", "stdout.3"},
 		},
+		{
+			"filters files without a rotation index",
+			args{
+				[]string{"server.1.log", "server.1.2.log", "server.log"},
+				regexp.MustCompile(`^server\.?([0-9.]*)\.log$`),
+			},
+			[]string{"server.1.log", "server.log"},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -133,3 +167,79 @@ func Test_sortFiles(t *testing.T) {
 		})
 	}
 }
+
+func Test_compileLogStreams(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		wantErr  bool
+	}{
+		{"accepts the default stdout regexp", stdoutRegexp.String(), false},
+		{"accepts a named rotation group", `^server(\.(?P<rotation>\d+))?(\.gz)?$`, false},
+		{"accepts separators in the rotation group", `^server([-_.][0-9]+)?\.log$`, false},
+		{"rejects a regexp without groups", `^server\.log$`, true},
+		{"rejects a rotation group capturing letters", `^server\.log(\.gz)?$`, true},
+		{"rejects a rotation group capturing any character", `^server\.log(.*)$`, true},
+		{"rejects a named rotation group capturing letters", `^server(?P<rotation>\.[a-z]+)?$`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := compileLogStreams([]LogStreamConfig{{Name: "server", FileName: tt.fileName}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("compileLogStreams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConcat_damagedRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-concat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	taskDir := filepath.Join(DirNameTasks, "starting_20200416T110149__kafka-0-broker__kafka-0-broker__1")
+	truncated := gzipMember(t, "second\n")
+	writeTestFiles(t, dir, map[string][]byte{
+		filepath.Join(taskDir, "stdout.2.gz"): gzipMember(t, "first\n"),
+		filepath.Join(taskDir, "stdout.1.gz"): truncated[:len(truncated)-4],
+		filepath.Join(taskDir, "stdout"):      []byte("third\n"),
+	})
+	_ = TakeLogDamages()
+	allPath := filepath.Join(dir, taskDir, stdoutAllFileName)
+	want := "first\nsecond\nthird\n"
+	for run := 1; run <= 2; run++ {
+		if err := Concat(dir, false); err != nil {
+			t.Fatalf("run %v: Concat() error = %v", run, err)
+		}
+		data, err := ioutil.ReadFile(allPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("run %v: %v = %q, want %q", run, stdoutAllFileName, data, want)
+		}
+	}
+	infos, err := ioutil.ReadDir(filepath.Join(dir, taskDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	if wantNames := []string{"stdout.1.gz" + DamagedSuffix, stdoutAllFileName}; !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("task files after Concat() = %v, want %v", names, wantNames)
+	}
+	if damages := TakeLogDamages(); len(damages) != 1 {
+		t.Errorf("Concat() recorded damages %v, want one", damages)
+	}
+	// New rotations are not concatenated over the existing file.
+	writeTestFiles(t, dir, map[string][]byte{filepath.Join(taskDir, "stdout"): []byte("fourth\n")})
+	if err := Concat(dir, false); err == nil {
+		t.Error("Concat() overwrote the concatenated log")
+	}
+	if data, _ := ioutil.ReadFile(allPath); string(data) != want {
+		t.Errorf("%v = %q after failed Concat(), want %q", stdoutAllFileName, data, want)
+	}
+}
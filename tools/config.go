@@ -0,0 +1,328 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+)
+
+// ConfigFileName is the name of the configuration file in the user configuration directory.
+const ConfigFileName = "sbun.json"
+
+// Config customizes how SBun reads a bundle.
+type Config struct {
+	LogStreams []LogStreamConfig `json:"logStreams"`
+	// DirNameParsers are the names of the task directory name parsers. Every directory name is parsed
+	// with the first parser which accepts it.
+	DirNameParsers []string `json:"dirNameParsers"`
+	// CustomDirNameParsers are regular expression parsers which can be used in DirNameParsers.
+	CustomDirNameParsers []DirNameParserConfig `json:"customDirNameParsers,omitempty"`
+}
+
+// LogStreamConfig describes the files of a log stream, e.g., stdout or server.log.
+type LogStreamConfig struct {
+	Name string `json:"name"`
+	// FileName is a regular expression matching the names of the stream files. The group named "rotation"
+	// or, if there is no such group, the first group captures the rotation index. Files with bigger index
+	// are older, a file without index is the newest.
+	FileName string `json:"fileName"`
+	// AllFileName is the name of the file to which Concat writes the stream. Default: <name>_all.
+	AllFileName string `json:"allFileName,omitempty"`
+	// Dirs are glob patterns of the directories with the stream files, relative to the task directory.
+	// Default: the task directory itself.
+	Dirs []string `json:"dirs,omitempty"`
+}
+
+// DirNameParserConfig describes a task directory name convention with a regular expression. The groups
+// named "name" and "id" capture the task name and ID and are required. The optional groups named "starting",
+// "running", "killed" and "failed" capture the timestamps of the task states.
+type DirNameParserConfig struct {
+	Name   string `json:"name"`
+	Regexp string `json:"regexp"`
+	// TimeLayout is the layout of the timestamps in the Go format. Default: 20060102T150405.
+	TimeLayout string `json:"timeLayout,omitempty"`
+}
+
+// LogStream is a compiled LogStreamConfig.
+type LogStream struct {
+	Name        string
+	FileName    *regexp.Regexp
+	AllFileName string
+	Dirs        []string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		LogStreams: []LogStreamConfig{
+			{
+				Name:        StreamStdout,
+				FileName:    stdoutRegexp.String(),
+				AllFileName: stdoutAllFileName,
+				Dirs:        []string{".", taskLogDirName, executorLogDirName},
+			},
+			{
+				Name:        StreamStderr,
+				FileName:    stderrRegexp.String(),
+				AllFileName: stderrAllFileName,
+				Dirs:        []string{".", taskLogDirName, executorLogDirName},
+			},
+		},
+		DirNameParsers: []string{DirNameParserSDK, DirNameParserMarathon},
+	}
+}
+
+var (
+	config               = DefaultConfig()
+	logStreams           = mustCompileLogStreams(config.LogStreams)
+	activeDirNameParsers = mustCompileDirNameParsers(config)
+)
+
+// CurrentConfig returns the configuration in use.
+func CurrentConfig() Config {
+	return config
+}
+
+// SetConfig validates the configuration and starts using it.
+func SetConfig(c Config) error {
+	streams, err := compileLogStreams(c.LogStreams)
+	if err != nil {
+		return err
+	}
+	parsers, err := compileDirNameParsers(c)
+	if err != nil {
+		return err
+	}
+	config = c
+	logStreams = streams
+	activeDirNameParsers = parsers
+	return nil
+}
+
+// LoadConfig reads the configuration from the file. If path is empty, it reads sbun.json from
+// the user configuration directory if the file exists there, otherwise the default configuration is used.
+func LoadConfig(path string) error {
+	if path == "" {
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return nil
+		}
+		path = filepath.Join(dir, ConfigFileName)
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("cannot read config file: %v", err)
+	}
+	c := DefaultConfig()
+	if err := json.Unmarshal(data, &c); err != nil {
+		return fmt.Errorf("cannot parse config file %v: %v", path, err)
+	}
+	if err := SetConfig(c); err != nil {
+		return fmt.Errorf("invalid config file %v: %v", path, err)
+	}
+	return nil
+}
+
+// LogStreamNames returns the names of the configured log streams.
+func LogStreamNames() []string {
+	names := make([]string, 0, len(logStreams))
+	for _, s := range logStreams {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func findLogStream(name string) (LogStream, error) {
+	for _, s := range logStreams {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	return LogStream{}, fmt.Errorf("unknown log stream %q", name)
+}
+
+func compileLogStreams(configs []LogStreamConfig) ([]LogStream, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no log streams configured")
+	}
+	streams := make([]LogStream, 0, len(configs))
+	names := make(map[string]bool)
+	for _, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("log stream name is empty")
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicate log stream %q", c.Name)
+		}
+		names[c.Name] = true
+		r, err := regexp.Compile(c.FileName)
+		if err != nil {
+			return nil, fmt.Errorf("cannot compile file name regexp of the log stream %q: %v", c.Name, err)
+		}
+		if err := checkRotationGroup(r); err != nil {
+			return nil, fmt.Errorf("file name regexp of the log stream %q: %v", c.Name, err)
+		}
+		s := LogStream{
+			Name:        c.Name,
+			FileName:    r,
+			AllFileName: c.AllFileName,
+			Dirs:        c.Dirs,
+		}
+		if s.AllFileName == "" {
+			s.AllFileName = c.Name + "_all"
+		}
+		if len(s.Dirs) == 0 {
+			s.Dirs = []string{"."}
+		}
+		for _, dir := range s.Dirs {
+			if _, err := filepath.Match(dir, ""); err != nil {
+				return nil, fmt.Errorf("invalid directory pattern %q of the log stream %q: %v", dir, c.Name, err)
+			}
+		}
+		streams = append(streams, s)
+	}
+	return streams, nil
+}
+
+// checkRotationGroup checks that the rotation group of the file name regexp exists and can capture
+// only digits and the separators trimmed by fileNumber, so that every matching file has a rotation index.
+func checkRotationGroup(r *regexp.Regexp) error {
+	index := rotationGroupIndex(r)
+	if index == 0 {
+		return fmt.Errorf("no rotation group")
+	}
+	re, err := syntax.Parse(r.String(), syntax.Perl)
+	if err != nil {
+		return err
+	}
+	group := findCapture(re, index)
+	if group == nil || !matchesOnlyDigits(group) {
+		return fmt.Errorf("rotation group %v must capture only digits and separators \".-_\"", index)
+	}
+	return nil
+}
+
+// rotationGroupIndex returns the index of the group named "rotation" or 1 if there is no such group,
+// 0 if the regexp has no groups.
+func rotationGroupIndex(r *regexp.Regexp) int {
+	for i, name := range r.SubexpNames() {
+		if name == "rotation" {
+			return i
+		}
+	}
+	if r.NumSubexp() == 0 {
+		return 0
+	}
+	return 1
+}
+
+func findCapture(re *syntax.Regexp, index int) *syntax.Regexp {
+	if re.Op == syntax.OpCapture && re.Cap == index {
+		return re
+	}
+	for _, sub := range re.Sub {
+		if found := findCapture(sub, index); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func matchesOnlyDigits(re *syntax.Regexp) bool {
+	isAllowed := func(r rune) bool {
+		return r >= '0' && r <= '9' || strings.ContainsRune(".-_", r)
+	}
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			if !isAllowed(r) {
+				return false
+			}
+		}
+	case syntax.OpCharClass:
+		// Rune holds the inclusive ranges of the class.
+		for i := 0; i < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1]; r++ {
+				if !isAllowed(r) {
+					return false
+				}
+			}
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return false
+	}
+	for _, sub := range re.Sub {
+		if !matchesOnlyDigits(sub) {
+			return false
+		}
+	}
+	return true
+}
+
+func mustCompileLogStreams(configs []LogStreamConfig) []LogStream {
+	streams, err := compileLogStreams(configs)
+	if err != nil {
+		panic(err)
+	}
+	return streams
+}
+
+// compileDirNameParsers returns the parsers of the configuration in order. Custom parsers cannot replace
+// the registered ones.
+func compileDirNameParsers(c Config) ([]DirNameParser, error) {
+	if len(c.DirNameParsers) == 0 {
+		return nil, fmt.Errorf("no task directory name parsers configured")
+	}
+	custom := make(map[string]DirNameParser)
+	for _, pc := range c.CustomDirNameParsers {
+		p, err := compileRegexpDirNameParser(pc)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := dirNameParsers[p.Name()]; ok {
+			return nil, fmt.Errorf("custom task directory name parser %q has the name of a built-in parser", p.Name())
+		}
+		if _, ok := custom[p.Name()]; ok {
+			return nil, fmt.Errorf("duplicate task directory name parser %q", p.Name())
+		}
+		custom[p.Name()] = p
+	}
+	parsers := make([]DirNameParser, 0, len(c.DirNameParsers))
+	for _, name := range c.DirNameParsers {
+		p, ok := dirNameParsers[name]
+		if !ok {
+			p, ok = custom[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown task directory name parser %q, known parsers: %v", name,
+				strings.Join(append(DirNameParserNames(), customNames(custom)...), ", "))
+		}
+		parsers = append(parsers, p)
+	}
+	return parsers, nil
+}
+
+func customNames(custom map[string]DirNameParser) []string {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func mustCompileDirNameParsers(c Config) []DirNameParser {
+	parsers, err := compileDirNameParsers(c)
+	if err != nil {
+		panic(err)
+	}
+	return parsers
+}
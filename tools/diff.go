@@ -0,0 +1,434 @@
+package tools
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// BundleDiff is the difference between two bundles of the same service.
+type BundleDiff struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+	// AddedTasks and RemovedTasks are task directory names.
+	AddedTasks   []string `json:"addedTasks"`
+	RemovedTasks []string `json:"removedTasks"`
+	// StateChanges compare the states of the latest runs of the tasks with the same name.
+	StateChanges      []ValueChange   `json:"stateChanges"`
+	RestartChanges    []RestartChange `json:"restartChanges"`
+	NewErrors         []LogTemplate   `json:"newErrors"`
+	GoneErrors        []LogTemplate   `json:"goneErrors"`
+	FileChanges       []FileChange    `json:"fileChanges"`
+	PlanStatusChanges []ValueChange   `json:"planStatusChanges"`
+}
+
+// ValueChange is a change of a value, e.g., a task state or a plan step status. Empty Before or After
+// means that the object didn't exist.
+type ValueChange struct {
+	Name   string `json:"name"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type RestartChange struct {
+	TaskName string `json:"taskName"`
+	Before   int    `json:"before"`
+	After    int    `json:"after"`
+}
+
+// FileChange is a change of a bundle file outside of the task directories.
+type FileChange struct {
+	Path string `json:"path"`
+	// Change is "added", "removed" or "changed".
+	Change string `json:"change"`
+	// Keys are the changed keys of JSON files, e.g., "env.BROKER_COUNT".
+	Keys []string `json:"keys,omitempty"`
+}
+
+// DiffBundles compares the before and the after bundles. Tasks are matched by ID, pod instances by task name.
+func DiffBundles(before string, after string) (BundleDiff, error) {
+	d := BundleDiff{Before: before, After: after}
+	beforeTasks, err := FindTasks(before)
+	if err != nil {
+		return d, fmt.Errorf("cannot find tasks in %v: %v", before, err)
+	}
+	afterTasks, err := FindTasks(after)
+	if err != nil {
+		return d, fmt.Errorf("cannot find tasks in %v: %v", after, err)
+	}
+	d.AddedTasks, d.RemovedTasks = diffTasks(beforeTasks, afterTasks)
+	d.StateChanges = diffStates(beforeTasks, afterTasks)
+	d.RestartChanges = diffRestarts(beforeTasks, afterTasks)
+	signatures, err := MineErrorSignatureGroups([][]Task{beforeTasks, afterTasks})
+	if err != nil {
+		return d, err
+	}
+	d.NewErrors = subtractTemplates(signatures[1], signatures[0])
+	d.GoneErrors = subtractTemplates(signatures[0], signatures[1])
+	if d.FileChanges, err = diffFiles(before, after); err != nil {
+		return d, err
+	}
+	beforePlans, err := FindPlans(before)
+	if err != nil {
+		return d, err
+	}
+	afterPlans, err := FindPlans(after)
+	if err != nil {
+		return d, err
+	}
+	d.PlanStatusChanges = diffValues(planStatuses(beforePlans), planStatuses(afterPlans))
+	return d, nil
+}
+
+func diffTasks(before []Task, after []Task) ([]string, []string) {
+	beforeIDs := make(map[string]bool)
+	for _, t := range before {
+		beforeIDs[t.ID] = true
+	}
+	afterIDs := make(map[string]bool)
+	added := make([]string, 0)
+	for _, t := range after {
+		afterIDs[t.ID] = true
+		if !beforeIDs[t.ID] {
+			added = append(added, t.DirName)
+		}
+	}
+	removed := make([]string, 0)
+	for _, t := range before {
+		if !afterIDs[t.ID] {
+			removed = append(removed, t.DirName)
+		}
+	}
+	return added, removed
+}
+
+func diffStates(before []Task, after []Task) []ValueChange {
+	states := func(tasks []Task) map[string]string {
+		m := make(map[string]string)
+		for _, t := range LatestRunPerInstance(tasks) {
+			m[t.Name] = t.State()
+		}
+		return m
+	}
+	return diffValues(states(before), states(after))
+}
+
+func diffRestarts(before []Task, after []Task) []RestartChange {
+	beforeRestarts := CountRestarts(before)
+	changes := make([]RestartChange, 0)
+	for name, n := range CountRestarts(after) {
+		if n > beforeRestarts[name] {
+			changes = append(changes, RestartChange{name, beforeRestarts[name], n})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].TaskName < changes[j].TaskName
+	})
+	return changes
+}
+
+// diffValues returns changes of the values ordered by name.
+func diffValues(before map[string]string, after map[string]string) []ValueChange {
+	changes := make([]ValueChange, 0)
+	for name, value := range after {
+		if before[name] != value {
+			changes = append(changes, ValueChange{name, before[name], value})
+		}
+	}
+	for name, value := range before {
+		if _, ok := after[name]; !ok {
+			changes = append(changes, ValueChange{name, value, ""})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// subtractTemplates returns the templates from a which are not in b.
+func subtractTemplates(a []LogTemplate, b []LogTemplate) []LogTemplate {
+	known := make(map[string]bool)
+	for _, t := range b {
+		known[t.Template] = true
+	}
+	result := make([]LogTemplate, 0)
+	for _, t := range a {
+		if !known[t.Template] {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func planStatuses(plans []Plan) map[string]string {
+	statuses := make(map[string]string)
+	for _, plan := range plans {
+		statuses[plan.Name] = plan.Status
+		for _, phase := range plan.Phases {
+			statuses[plan.Name+" / "+phase.Name] = phase.Status
+			for _, step := range phase.Steps {
+				statuses[plan.Name+" / "+phase.Name+" / "+step.Name] = step.Status
+			}
+		}
+	}
+	return statuses
+}
+
+// diffFiles compares the files outside of the task directories.
+func diffFiles(before string, after string) ([]FileChange, error) {
+	beforeFiles, err := bundleFiles(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFiles, err := bundleFiles(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]FileChange, 0)
+	for _, rel := range afterFiles {
+		if !containsPath(beforeFiles, rel) {
+			changes = append(changes, FileChange{Path: rel, Change: "added"})
+			continue
+		}
+		beforePath, afterPath := filepath.Join(before, rel), filepath.Join(after, rel)
+		same, err := sameFiles(beforePath, afterPath)
+		if err != nil {
+			return nil, err
+		}
+		if same {
+			continue
+		}
+		change := FileChange{Path: rel, Change: "changed"}
+		if change.Keys, err = diffJSONFiles(beforePath, afterPath); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	for _, rel := range beforeFiles {
+		if !containsPath(afterFiles, rel) {
+			changes = append(changes, FileChange{Path: rel, Change: "removed"})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// bundleFiles returns the paths of the regular files outside of the task directories relative to the bundle.
+// Directories created by SBun, like views and extracted bundles, are skipped.
+func bundleFiles(bundlePath string) ([]string, error) {
+	files := make([]string, 0)
+	err := filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(bundlePath, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel == DirNameTasks {
+				return filepath.SkipDir
+			}
+			if rel != "." && isGeneratedDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, rel)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// sameFiles reports whether the files have the same content. Sizes are compared first, so that
+// only the files of the same size are read.
+func sameFiles(a string, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if aInfo.Size() != bInfo.Size() {
+		return false, nil
+	}
+	aHash, err := hashFile(a)
+	if err != nil {
+		return false, err
+	}
+	bHash, err := hashFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(aHash, bHash), nil
+}
+
+func hashFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCloser(f)
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("cannot read %v: %v", path, err)
+	}
+	return h.Sum(nil), nil
+}
+
+// maxJSONDiffSize is the size of the biggest JSON file which is parsed to find the changed keys.
+const maxJSONDiffSize = 16 << 20
+
+// diffJSONFiles returns the changed keys of the JSON files, see diffJSON. Other files and JSON files
+// bigger than maxJSONDiffSize are not read and have no keys.
+func diffJSONFiles(before string, after string) ([]string, error) {
+	if strings.ToLower(filepath.Ext(before)) != ".json" {
+		return nil, nil
+	}
+	data := make([][]byte, 0, 2)
+	for _, path := range []string{before, after} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > maxJSONDiffSize {
+			return nil, nil
+		}
+		d, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, d)
+	}
+	return diffJSON(data[0], data[1]), nil
+}
+
+func containsPath(sorted []string, path string) bool {
+	i := sort.SearchStrings(sorted, path)
+	return i < len(sorted) && sorted[i] == path
+}
+
+// diffJSON returns the keys with different values if both documents are JSON.
+func diffJSON(before []byte, after []byte) []string {
+	var b, a interface{}
+	if json.Unmarshal(before, &b) != nil || json.Unmarshal(after, &a) != nil {
+		return nil
+	}
+	bFlat, aFlat := make(map[string]string), make(map[string]string)
+	flattenJSON("", b, bFlat)
+	flattenJSON("", a, aFlat)
+	keys := make([]string, 0)
+	for _, c := range diffValues(bFlat, aFlat) {
+		keys = append(keys, c.Name)
+	}
+	return keys
+}
+
+func flattenJSON(prefix string, v interface{}, out map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			flattenJSON(join(key), value, out)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flattenJSON(join(fmt.Sprintf("%d", i)), value, out)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		out[prefix] = string(data)
+	}
+}
+
+// WriteBundleDiff prints the difference in the text or json format.
+func WriteBundleDiff(w io.Writer, d BundleDiff, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(d)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	section := func(title string, n int) bool {
+		_, _ = fmt.Fprintf(tw, "\n%v: %v\n", title, n)
+		return n != 0
+	}
+	_, _ = fmt.Fprintf(tw, "Before: %v\nAfter:  %v\n", d.Before, d.After)
+	if section("Added tasks", len(d.AddedTasks)) {
+		for _, name := range d.AddedTasks {
+			_, _ = fmt.Fprintf(tw, "  + %v\n", name)
+		}
+	}
+	if section("Removed tasks", len(d.RemovedTasks)) {
+		for _, name := range d.RemovedTasks {
+			_, _ = fmt.Fprintf(tw, "  - %v\n", name)
+		}
+	}
+	if section("Task state changes", len(d.StateChanges)) {
+		for _, c := range d.StateChanges {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t-> %v\n", c.Name, orNone(c.Before), orNone(c.After))
+		}
+	}
+	if section("New restarts", len(d.RestartChanges)) {
+		for _, c := range d.RestartChanges {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t-> %v\n", c.TaskName, c.Before, c.After)
+		}
+	}
+	if section("New error signatures", len(d.NewErrors)) {
+		for _, t := range d.NewErrors {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\n", t.Count, t.Template)
+		}
+	}
+	if section("Error signatures which are gone", len(d.GoneErrors)) {
+		for _, t := range d.GoneErrors {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\n", t.Count, t.Template)
+		}
+	}
+	if section("Changed files", len(d.FileChanges)) {
+		for _, c := range d.FileChanges {
+			keys := ""
+			if len(c.Keys) != 0 {
+				keys = strings.Join(c.Keys, ", ")
+			}
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\n", c.Change, c.Path, keys)
+		}
+	}
+	if section("Plan status changes", len(d.PlanStatusChanges)) {
+		for _, c := range d.PlanStatusChanges {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t-> %v\n", c.Name, orNone(c.Before), orNone(c.After))
+		}
+	}
+	return tw.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "(none)"
+	}
+	return s
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffBundles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	log := func(lines ...string) []byte {
+		return []byte(strings.Join(lines, "\n") + "\n")
+	}
+	orders := "2020-04-16 11:01:00,000 ERROR Cannot reach the leader of the topic orders"
+	events := "2020-04-16 11:02:00,000 ERROR Cannot reach the leader of the topic events"
+	disk := "2020-04-16 11:03:00,000 FATAL Disk is full"
+	alpha := "2020-04-16 11:04:00,000 ERROR Session expired for the client alpha"
+	beta := "2020-04-16 11:05:00,000 ERROR Session expired for the client beta"
+	before, after := filepath.Join(dir, "before"), filepath.Join(dir, "after")
+	writeTestFiles(t, before, map[string][]byte{
+		"tasks/starting_20200416T110000-running_20200416T110100__kafka-0-broker__kafka-0-broker__a/stdout": log(orders, events),
+		"tasks/starting_20200416T110000-running_20200416T110100__kafka-1-broker__kafka-1-broker__b/stdout": log(alpha, beta),
+		"scheduler/env.json": []byte(`{"env": {"BROKER_COUNT": "2", "BROKER_MEM": "1024"}}`),
+		"removed.txt":        []byte("removed\n"),
+	})
+	writeTestFiles(t, after, map[string][]byte{
+		// The same errors in a different order and only one of the session errors.
+		"tasks/starting_20200416T110000-running_20200416T110100__kafka-0-broker__kafka-0-broker__a/stdout": log(events, orders),
+		"tasks/starting_20200416T110000-failed_20200416T110500__kafka-1-broker__kafka-1-broker__b/stdout":  log(alpha),
+		"tasks/starting_20200416T111000-running_20200416T111100__kafka-1-broker__kafka-1-broker__c/stdout": log(disk),
+		"scheduler/env.json": []byte(`{"env": {"BROKER_COUNT": "3", "BROKER_MEM": "1024"}}`),
+	})
+	d, err := DiffBundles(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	added := []string{"starting_20200416T111000-running_20200416T111100__kafka-1-broker__kafka-1-broker__c"}
+	if !reflect.DeepEqual(d.AddedTasks, added) {
+		t.Errorf("AddedTasks = %v, want %v", d.AddedTasks, added)
+	}
+	if len(d.RemovedTasks) != 0 {
+		t.Errorf("RemovedTasks = %v, want none", d.RemovedTasks)
+	}
+	if want := []RestartChange{{"kafka-1-broker", 0, 1}}; !reflect.DeepEqual(d.RestartChanges, want) {
+		t.Errorf("RestartChanges = %v, want %v", d.RestartChanges, want)
+	}
+	if len(d.GoneErrors) != 0 {
+		t.Errorf("GoneErrors = %v, want none", d.GoneErrors)
+	}
+	if len(d.NewErrors) != 1 || d.NewErrors[0].Template != "FATAL Disk is full" || d.NewErrors[0].Count != 1 {
+		t.Errorf("NewErrors = %v, want only the FATAL error", d.NewErrors)
+	}
+	wantFiles := []FileChange{
+		{Path: "removed.txt", Change: "removed"},
+		{Path: filepath.Join("scheduler", "env.json"), Change: "changed", Keys: []string{"env.BROKER_COUNT"}},
+	}
+	if !reflect.DeepEqual(d.FileChanges, wantFiles) {
+		t.Errorf("FileChanges = %v, want %v", d.FileChanges, wantFiles)
+	}
+}
+
+func Test_diffFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	before, after := filepath.Join(dir, "before"), filepath.Join(dir, "after")
+	writeTestFiles(t, before, map[string][]byte{
+		"same.txt":       []byte("same\n"),
+		"same_size.txt":  []byte("abc\n"),
+		"other_size.txt": []byte("abc\n"),
+	})
+	writeTestFiles(t, after, map[string][]byte{
+		"same.txt":       []byte("same\n"),
+		"same_size.txt":  []byte("abd\n"),
+		"other_size.txt": []byte("abcd\n"),
+		// Views and extracted bundles are not parts of the bundle.
+		filepath.Join("view", ViewMarkerFileName):           nil,
+		filepath.Join("view", "new.txt"):                    []byte("new\n"),
+		filepath.Join("extracted", ExtractManifestFileName): nil,
+		filepath.Join("extracted", "new.txt"):               []byte("new\n"),
+	})
+	changes, err := diffFiles(before, after)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{
+		{Path: "other_size.txt", Change: "changed"},
+		{Path: "same_size.txt", Change: "changed"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("diffFiles() = %v, want %v", changes, want)
+	}
+}
+
+func Test_diffJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   []string
+	}{
+		{"returns changed, added and removed keys", `{"a": 1, "b": {"c": 2}, "d": 3}`, `{"a": 1, "b": {"c": 4}, "e": 5}`,
+			[]string{"b.c", "d", "e"}},
+		{"indexes arrays", `{"a": [1, 2]}`, `{"a": [1, 3, 4]}`, []string{"a.1", "a.2"}},
+		{"ignores non-JSON documents", `{"a": 1}`, `a = 2`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := diffJSON([]byte(tt.before), []byte(tt.after)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirNameParser parses the task directory names of a naming convention.
+type DirNameParser interface {
+	// Name is the name of the parser in the configuration.
+	Name() string
+	// Parse returns the task with the ID, name, directory name and timestamps found in the directory name.
+	// It returns an error if the directory name doesn't follow the convention.
+	Parse(dirName string) (Task, error)
+}
+
+// DirNameFormatter is implemented by the parsers which can format a task directory name,
+// so that parsing the formatted name returns the same task.
+type DirNameFormatter interface {
+	Format(t Task) string
+}
+
+// Names of the built-in directory name parsers.
+const (
+	DirNameParserSDK      = "sdk"
+	DirNameParserMarathon = "marathon"
+)
+
+// taskTimeLayout is the layout of the timestamps in the SDK task directory names.
+const taskTimeLayout = "20060102T150405"
+
+// dirNameParsers are the registered parsers by name.
+var dirNameParsers = builtinDirNameParsers()
+
+// RegisterDirNameParser makes the parser available for the configuration. It returns an error if there is
+// a parser with the same name already.
+func RegisterDirNameParser(p DirNameParser) error {
+	if _, ok := dirNameParsers[p.Name()]; ok {
+		return fmt.Errorf("duplicate task directory name parser %q", p.Name())
+	}
+	dirNameParsers[p.Name()] = p
+	return nil
+}
+
+// DirNameParserNames returns the names of the registered parsers.
+func DirNameParserNames() []string {
+	names := make([]string, 0, len(dirNameParsers))
+	for name := range dirNameParsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func builtinDirNameParsers() map[string]DirNameParser {
+	parsers := make(map[string]DirNameParser)
+	for _, p := range []DirNameParser{
+		sdkDirNameParser{},
+		mustCompileRegexpDirNameParser(DirNameParserConfig{
+			Name: DirNameParserMarathon,
+			// nginx.instance-5f4bfc5a-8a3e-11ea-9e3c-0242ac110002._app.1, group_app.5f4bfc5a-8a3e-11ea-...
+			Regexp: `^(?P<id>(?P<name>.+?)\.(instance-)?[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}` +
+				`(\._app\.[0-9]+)?)$`,
+		}),
+	} {
+		parsers[p.Name()] = p
+	}
+	return parsers
+}
+
+// parseTaskDirName parses the directory name with the first configured parser which accepts it.
+func parseTaskDirName(dirName string) (Task, error) {
+	errs := make([]string, 0, len(activeDirNameParsers))
+	for _, p := range activeDirNameParsers {
+		task, err := p.Parse(dirName)
+		if err == nil {
+			return task, nil
+		}
+		errs = append(errs, fmt.Sprintf("%v: %v", p.Name(), err))
+	}
+	return Task{}, fmt.Errorf("no task directory name parser accepts %v: %v", dirName, strings.Join(errs, "; "))
+}
+
+// sdkDirNameParser parses the names created by the service diagnostics bundle tool of DC/OS SDK services:
+// starting_20200416T110149-running_20200416T112050-killed_20200416T114052__kafka-2-broker__06e119a6-b6bb-4dae-8229-799cdf54c752
+type sdkDirNameParser struct{}
+
+// starting_20200416T110149-running_20200416T112050
+var sdkStatusesRegexp = regexp.MustCompile(`^((failed|starting|running|killed)_[0-9T]*-?)+$`)
+
+func (sdkDirNameParser) Name() string {
+	return DirNameParserSDK
+}
+
+func (sdkDirNameParser) Parse(dirName string) (Task, error) {
+	task := Task{}
+	tokens := strings.SplitN(dirName, "__", 2)
+	if len(tokens) != 2 || !sdkStatusesRegexp.MatchString(tokens[0]) {
+		return task, fmt.Errorf("cannot parse statuses for task: %v", dirName)
+	}
+	var ok bool
+	if task.Name, task.ID, ok = splitSDKNameAndID(tokens[1]); !ok {
+		return task, fmt.Errorf("cannot parse ID and name for task: %v", dirName)
+	}
+	statuses := make(map[string]time.Time)
+	for _, token := range taskStatusRegexp.FindAllStringSubmatch(tokens[0], -1) {
+		t, err := time.Parse(taskTimeLayout, token[2])
+		if err != nil {
+			return task, err
+		}
+		statuses[token[1]] = t
+	}
+	task.DirName = dirName
+	task.Staring = statuses[StateStarting]
+	task.Running = statuses[StateRunning]
+	task.Killed = statuses[StateKilled]
+	task.Failed = statuses[StateFailed]
+	return task, nil
+}
+
+// splitSDKNameAndID splits "<name>__<ID>". Both the name and the ID can contain "__", e.g., SDK task IDs
+// are often "<name>__<UUID>", so the split where the ID starts with the name is preferred, otherwise
+// the ID is the part after the last "__".
+func splitSDKNameAndID(s string) (string, string, bool) {
+	parts := strings.Split(s, "__")
+	if len(parts) < 2 {
+		return "", "", false
+	}
+	for i := 1; i < len(parts); i++ {
+		name, id := strings.Join(parts[:i], "__"), strings.Join(parts[i:], "__")
+		if strings.HasPrefix(id, name+"__") {
+			return name, id, true
+		}
+	}
+	name, id := strings.Join(parts[:len(parts)-1], "__"), parts[len(parts)-1]
+	return name, id, name != "" && id != ""
+}
+
+func (sdkDirNameParser) Format(t Task) string {
+	statuses := make([]string, 0, 4)
+	for _, s := range []struct {
+		name string
+		t    time.Time
+	}{
+		{StateStarting, t.Staring},
+		{StateRunning, t.Running},
+		{StateKilled, t.Killed},
+		{StateFailed, t.Failed},
+	} {
+		if !s.t.IsZero() {
+			statuses = append(statuses, s.name+"_"+s.t.Format(taskTimeLayout))
+		}
+	}
+	return strings.Join(statuses, "-") + "__" + t.Name + "__" + t.ID
+}
+
+// regexpDirNameParser parses the directory names with a regular expression, see DirNameParserConfig.
+type regexpDirNameParser struct {
+	name       string
+	r          *regexp.Regexp
+	timeLayout string
+}
+
+func compileRegexpDirNameParser(c DirNameParserConfig) (regexpDirNameParser, error) {
+	p := regexpDirNameParser{name: c.Name, timeLayout: c.TimeLayout}
+	if c.Name == "" {
+		return p, fmt.Errorf("task directory name parser name is empty")
+	}
+	r, err := regexp.Compile(c.Regexp)
+	if err != nil {
+		return p, fmt.Errorf("cannot compile regexp of the task directory name parser %q: %v", c.Name, err)
+	}
+	groups := make(map[string]bool)
+	for _, g := range r.SubexpNames() {
+		groups[g] = true
+	}
+	if !groups["name"] || !groups["id"] {
+		return p, fmt.Errorf("regexp of the task directory name parser %q has no \"name\" or \"id\" group", c.Name)
+	}
+	p.r = r
+	if p.timeLayout == "" {
+		p.timeLayout = taskTimeLayout
+	}
+	return p, nil
+}
+
+func mustCompileRegexpDirNameParser(c DirNameParserConfig) regexpDirNameParser {
+	p, err := compileRegexpDirNameParser(c)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p regexpDirNameParser) Name() string {
+	return p.name
+}
+
+func (p regexpDirNameParser) Parse(dirName string) (Task, error) {
+	task := Task{DirName: dirName}
+	match := p.r.FindStringSubmatch(dirName)
+	if match == nil {
+		return task, fmt.Errorf("directory name doesn't match %v", p.r)
+	}
+	for i, group := range p.r.SubexpNames() {
+		value := match[i]
+		var dst *time.Time
+		switch group {
+		case "name":
+			task.Name = value
+		case "id":
+			task.ID = value
+		case StateStarting:
+			dst = &task.Staring
+		case StateRunning:
+			dst = &task.Running
+		case StateKilled:
+			dst = &task.Killed
+		case StateFailed:
+			dst = &task.Failed
+		}
+		if dst != nil && value != "" {
+			t, err := time.Parse(p.timeLayout, value)
+			if err != nil {
+				return task, fmt.Errorf("cannot parse the %v time: %v", group, err)
+			}
+			*dst = t
+		}
+	}
+	if task.Name == "" || task.ID == "" {
+		return task, fmt.Errorf("cannot parse ID and name for task: %v", dirName)
+	}
+	return task, nil
+}
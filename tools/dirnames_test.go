@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_parseTaskDirName(t *testing.T) {
+	ts := func(s string) time.Time {
+		t, _ := time.Parse(taskTimeLayout, s)
+		return t
+	}
+	tests := []struct {
+		dirName string
+		want    Task
+		wantErr bool
+	}{
+		{
+			dirName: "starting_20200416T110149-killed_20200416T114052__kafka-2-broker__06e119a6",
+			want:    Task{Name: "kafka-2-broker", ID: "06e119a6", Staring: ts("20200416T110149"), Killed: ts("20200416T114052")},
+		},
+		{
+			dirName: "running_20200416T112050__kafka-2-broker__kafka-2-broker__06e119a6",
+			want:    Task{Name: "kafka-2-broker", ID: "kafka-2-broker__06e119a6", Running: ts("20200416T112050")},
+		},
+		{
+			dirName: "running_20200416T112050__my__task__06e119a6",
+			want:    Task{Name: "my__task", ID: "06e119a6", Running: ts("20200416T112050")},
+		},
+		{
+			dirName: "nginx.instance-5f4bfc5a-8a3e-11ea-9e3c-0242ac110002._app.1",
+			want:    Task{Name: "nginx", ID: "nginx.instance-5f4bfc5a-8a3e-11ea-9e3c-0242ac110002._app.1"},
+		},
+		{
+			dirName: "group_app.v2.5f4bfc5a-8a3e-11ea-9e3c-0242ac110002",
+			want:    Task{Name: "group_app.v2", ID: "group_app.v2.5f4bfc5a-8a3e-11ea-9e3c-0242ac110002"},
+		},
+		{dirName: "running_20200416T112050__kafka-2-broker", wantErr: true},
+		{dirName: "something", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTaskDirName(tt.dirName)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTaskDirName(%v) error = %v, wantErr %v", tt.dirName, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		tt.want.DirName = tt.dirName
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTaskDirName(%v) = %+v, want %+v", tt.dirName, got, tt.want)
+		}
+	}
+}
+
+func Test_sdkDirNameParser_Format(t *testing.T) {
+	p := sdkDirNameParser{}
+	for _, dirName := range []string{
+		"starting_20200416T110149-running_20200416T112050-killed_20200416T114052__kafka-2-broker__06e119a6",
+		"failed_20200416T110149__kafka-2-broker__kafka-2-broker__06e119a6",
+		"running_20200416T112050__my__task__06e119a6",
+	} {
+		task, err := p.Parse(dirName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p.Format(task); got != dirName {
+			t.Errorf("Format(Parse(%v)) = %v", dirName, got)
+		}
+	}
+}
+
+func TestSetConfig_dirNameParsers(t *testing.T) {
+	defer func() { _ = SetConfig(DefaultConfig()) }()
+	c := DefaultConfig()
+	c.CustomDirNameParsers = []DirNameParserConfig{{
+		Name:       "jenkins",
+		Regexp:     `^(?P<name>jenkins-agent-[a-z]+)-(?P<id>[0-9a-f]+)-(?P<starting>[0-9]{8})$`,
+		TimeLayout: "20060102",
+	}}
+	c.DirNameParsers = []string{"jenkins", DirNameParserSDK}
+	if err := SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	got, err := parseTaskDirName("jenkins-agent-linux-0a1b-20200416")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Task{Name: "jenkins-agent-linux", ID: "0a1b", DirName: "jenkins-agent-linux-0a1b-20200416",
+		Staring: time.Date(2020, 4, 16, 0, 0, 0, 0, time.UTC)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseTaskDirName() = %+v, want %+v", got, want)
+	}
+	if _, err := parseTaskDirName("nginx.5f4bfc5a-8a3e-11ea-9e3c-0242ac110002"); err == nil {
+		t.Errorf("parseTaskDirName() accepts a name of a parser which is not configured")
+	}
+	for _, parsers := range [][]string{{"unknown"}, {}} {
+		c.DirNameParsers = parsers
+		if err := SetConfig(c); err == nil {
+			t.Errorf("SetConfig() accepts parsers %v", parsers)
+		}
+	}
+}
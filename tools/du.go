@@ -0,0 +1,305 @@
+package tools
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// StreamOther is the name of the files which don't belong to any log stream.
+const StreamOther = "other"
+
+// StreamUsage is the disk usage of a log stream of a task.
+type StreamUsage struct {
+	Stream string `json:"stream"`
+	// Files is the number of files, i.e., rotations, of the stream.
+	Files        int   `json:"files"`
+	Compressed   int64 `json:"compressed"`
+	Uncompressed int64 `json:"uncompressed"`
+}
+
+// TaskUsage is the disk usage of a task directory.
+type TaskUsage struct {
+	PodType      string        `json:"podType"`
+	TaskName     string        `json:"taskName"`
+	TaskID       string        `json:"taskId"`
+	DirName      string        `json:"dirName"`
+	Compressed   int64         `json:"compressed"`
+	Uncompressed int64         `json:"uncompressed"`
+	Streams      []StreamUsage `json:"streams"`
+}
+
+// FileUsage is the size of a file in a task directory.
+type FileUsage struct {
+	Path         string `json:"path"`
+	Stream       string `json:"stream"`
+	Compressed   int64  `json:"compressed"`
+	Uncompressed int64  `json:"uncompressed"`
+}
+
+// DiskUsage lists tasks ordered by pod type and then by the uncompressed size, the biggest first,
+// and files ordered by the uncompressed size.
+type DiskUsage struct {
+	Tasks []TaskUsage `json:"tasks"`
+	Files []FileUsage `json:"files"`
+}
+
+// MeasureDiskUsage measures the size of the task directories. The uncompressed size of gzip files is read
+// from the gzip trailer, which is fast but wrong for files bigger than 4 GiB and files with several
+// gzip members. If exact is true, the files are decompressed instead.
+func MeasureDiskUsage(tasks []Task, exact bool) (DiskUsage, error) {
+	du := DiskUsage{}
+	for _, task := range tasks {
+		tu, files, err := measureTask(task, exact)
+		if err != nil {
+			return du, err
+		}
+		du.Tasks = append(du.Tasks, tu)
+		du.Files = append(du.Files, files...)
+	}
+	sort.SliceStable(du.Tasks, func(i, j int) bool {
+		if du.Tasks[i].PodType != du.Tasks[j].PodType {
+			return du.Tasks[i].PodType < du.Tasks[j].PodType
+		}
+		return du.Tasks[i].Uncompressed > du.Tasks[j].Uncompressed
+	})
+	sort.SliceStable(du.Files, func(i, j int) bool {
+		return du.Files[i].Uncompressed > du.Files[j].Uncompressed
+	})
+	return du, nil
+}
+
+func measureTask(task Task, exact bool) (TaskUsage, []FileUsage, error) {
+	tu := TaskUsage{
+		PodType:  task.PodType(),
+		TaskName: task.Name,
+		TaskID:   task.ID,
+		DirName:  task.DirName,
+	}
+	streamFiles, err := taskStreamFiles(task)
+	if err != nil {
+		return tu, nil, err
+	}
+	streams := make(map[string]*StreamUsage)
+	for _, name := range append(LogStreamNames(), StreamOther) {
+		tu.Streams = append(tu.Streams, StreamUsage{Stream: name})
+	}
+	for i := range tu.Streams {
+		streams[tu.Streams[i].Stream] = &tu.Streams[i]
+	}
+	files := make([]FileUsage, 0)
+	err = filepath.Walk(task.DirNameAbsolute, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot walk into path %v: %v\n", path, err)
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		uncompressed := info.Size()
+		if filepath.Ext(path) == ".gz" {
+			if uncompressed, err = gzipUncompressedSize(path, exact); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot get uncompressed size of %v: %v\n", path, err)
+				uncompressed = info.Size()
+			}
+		}
+		stream, ok := streamFiles[path]
+		if !ok {
+			stream = StreamOther
+		}
+		su := streams[stream]
+		su.Files++
+		su.Compressed += info.Size()
+		su.Uncompressed += uncompressed
+		tu.Compressed += info.Size()
+		tu.Uncompressed += uncompressed
+		rel, _ := filepath.Rel(filepath.Dir(task.DirNameAbsolute), path)
+		files = append(files, FileUsage{
+			Path:         filepath.Join(DirNameTasks, rel),
+			Stream:       stream,
+			Compressed:   info.Size(),
+			Uncompressed: uncompressed,
+		})
+		return nil
+	})
+	return tu, files, err
+}
+
+// taskStreamFiles maps paths of the task log files to the names of their streams.
+func taskStreamFiles(task Task) (map[string]string, error) {
+	streamFiles := make(map[string]string)
+	for _, s := range logStreams {
+		dirs, err := s.taskDirs(task.DirNameAbsolute)
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			paths, allPath, err := s.files(dir)
+			if err != nil {
+				return nil, err
+			}
+			if allPath != "" {
+				paths = append(paths, allPath)
+			}
+			for _, path := range paths {
+				if _, ok := streamFiles[path]; !ok {
+					streamFiles[path] = s.Name
+				}
+			}
+		}
+	}
+	return streamFiles, nil
+}
+
+func gzipUncompressedSize(path string, exact bool) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer closeCloser(f)
+	if exact {
+		r, err := gzip.NewReader(f)
+		if err != nil {
+			return 0, err
+		}
+		return io.Copy(ioutil.Discard, r)
+	}
+	// The last 4 bytes of a gzip file are the uncompressed size modulo 2^32.
+	if _, err := f.Seek(-4, io.SeekEnd); err != nil {
+		return 0, err
+	}
+	trailer := make([]byte, 4)
+	if _, err := io.ReadFull(f, trailer); err != nil {
+		return 0, err
+	}
+	return int64(binary.LittleEndian.Uint32(trailer)), nil
+}
+
+// WriteDiskUsage prints the disk usage in the text, csv or json format. Only top largest files are printed,
+// if top is 0, all the files are printed.
+func WriteDiskUsage(w io.Writer, du DiskUsage, format string, top int) error {
+	if top > 0 && len(du.Files) > top {
+		du.Files = du.Files[:top]
+	}
+	switch format {
+	case "text":
+		return writeDiskUsageText(w, du)
+	case "csv":
+		return writeDiskUsageCsv(w, du)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(du)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+func writeDiskUsageText(w io.Writer, du DiskUsage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	podType := ""
+	var podCompressed, podUncompressed int64
+	printPodTotal := func() {
+		if podType != "" {
+			_, _ = fmt.Fprintf(tw, "  total\t\t%v\t%v\t\n", humanSize(podCompressed), humanSize(podUncompressed))
+		}
+	}
+	_, _ = fmt.Fprintln(tw, "POD TYPE / TASK\tSTREAM\tCOMPRESSED\tUNCOMPRESSED\tFILES")
+	for i, tu := range du.Tasks {
+		if i == 0 || tu.PodType != podType {
+			printPodTotal()
+			podType, podCompressed, podUncompressed = tu.PodType, 0, 0
+			_, _ = fmt.Fprintf(tw, "%v\t\t\t\t\n", podType)
+		}
+		podCompressed += tu.Compressed
+		podUncompressed += tu.Uncompressed
+		_, _ = fmt.Fprintf(tw, "  %v %v\t\t%v\t%v\t\n",
+			tu.TaskName, shortID(tu.TaskID), humanSize(tu.Compressed), humanSize(tu.Uncompressed))
+		for _, su := range tu.Streams {
+			if su.Files == 0 {
+				continue
+			}
+			_, _ = fmt.Fprintf(tw, "\t%v\t%v\t%v\t%v\n",
+				su.Stream, humanSize(su.Compressed), humanSize(su.Uncompressed), su.Files)
+		}
+	}
+	printPodTotal()
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COMPRESSED\tUNCOMPRESSED\tSTREAM\tLARGEST FILES")
+	for _, fu := range du.Files {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n",
+			humanSize(fu.Compressed), humanSize(fu.Uncompressed), fu.Stream, fu.Path)
+	}
+	return tw.Flush()
+}
+
+func writeDiskUsageCsv(w io.Writer, du DiskUsage) error {
+	csvWriter := csv.NewWriter(w)
+	err := csvWriter.Write([]string{
+		"Pod Type",
+		"Task Name",
+		"Task ID",
+		"Dir Name",
+		"Stream",
+		"Files",
+		"Compressed",
+		"Uncompressed",
+	})
+	if err != nil {
+		return fmt.Errorf("cannot write to the CSV output: %v", err.Error())
+	}
+	for _, tu := range du.Tasks {
+		for _, su := range tu.Streams {
+			err := csvWriter.Write([]string{
+				tu.PodType,
+				tu.TaskName,
+				tu.TaskID,
+				tu.DirName,
+				su.Stream,
+				strconv.Itoa(su.Files),
+				strconv.FormatInt(su.Compressed, 10),
+				strconv.FormatInt(su.Uncompressed, 10),
+			})
+			if err != nil {
+				return fmt.Errorf("cannot write to the CSV output: %v", err.Error())
+			}
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// shortID returns the first block of the task ID UUID, which is usually enough to distinguish tasks.
+func shortID(id string) string {
+	if i := strings.IndexByte(id, '-'); i > 0 {
+		return id[:i]
+	}
+	return id
+}
+
+// humanSize formats the size in bytes, e.g., 1536 is "1.5 KiB".
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
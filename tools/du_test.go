@@ -0,0 +1,142 @@
+package tools
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gzipMember(t *testing.T, s string) []byte {
+	buf := bytes.Buffer{}
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// writeTestFiles writes the files with paths relative to dir, creating the parent directories.
+func writeTestFiles(t *testing.T, dir string, files map[string][]byte) {
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func Test_humanSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1024*1024 - 1, "1024.0 KiB"},
+		{5 * 1024 * 1024, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+		{1 << 62, "4.0 EiB"},
+	}
+	for _, tt := range tests {
+		if got := humanSize(tt.size); got != tt.want {
+			t.Errorf("humanSize(%v) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func Test_gzipUncompressedSize(t *testing.T) {
+	first := gzipMember(t, strings.Repeat("a", 1000))
+	second := gzipMember(t, strings.Repeat("b", 300))
+	tests := []struct {
+		name    string
+		data    []byte
+		exact   bool
+		want    int64
+		wantErr bool
+	}{
+		{"reads the size from the trailer", first, false, 1000, false},
+		{"decompresses the file", first, true, 1000, false},
+		// The trailer has the size of the last member only.
+		{"reads the size of the last member from the trailer", bytes.Join([][]byte{first, second}, nil), false, 300, false},
+		{"decompresses all the members", bytes.Join([][]byte{first, second}, nil), true, 1300, false},
+		{"fails to decompress a truncated file", first[:len(first)-6], true, 0, true},
+		{"fails to read the trailer of a tiny file", first[:3], false, 0, true},
+	}
+	dir, err := ioutil.TempDir("", "sbun-du")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".gz")
+			if err := ioutil.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := gzipUncompressedSize(path, tt.exact)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("gzipUncompressedSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("gzipUncompressedSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_measureTask(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-du")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	rotation := gzipMember(t, strings.Repeat("x", 5000))
+	truncated := gzipMember(t, strings.Repeat("y", 5000))
+	truncated = truncated[:len(truncated)/2]
+	writeTestFiles(t, dir, map[string][]byte{
+		"kafka-0-broker/stdout":        []byte("12345"),
+		"kafka-0-broker/stdout.1.gz":   rotation,
+		"kafka-0-broker/stderr.1.gz":   truncated,
+		"kafka-0-broker/task/stderr":   []byte("123"),
+		"kafka-0-broker/kafka/data.db": []byte("1234567"),
+	})
+	task := Task{Name: "kafka-0-broker", ID: "kafka-0-broker__1", DirName: "kafka-0-broker",
+		DirNameAbsolute: filepath.Join(dir, "kafka-0-broker")}
+	tu, files, err := measureTask(task, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tu.PodType != "kafka" || len(files) != 5 {
+		t.Errorf("measureTask() = pod type %v, %v files, want kafka, 5 files", tu.PodType, len(files))
+	}
+	want := map[string]StreamUsage{
+		StreamStdout: {StreamStdout, 2, 5 + int64(len(rotation)), 5005},
+		// The uncompressed size of the truncated file is unknown, so its compressed size is used.
+		StreamStderr: {StreamStderr, 2, 3 + int64(len(truncated)), 3 + int64(len(truncated))},
+		StreamOther:  {StreamOther, 1, 7, 7},
+	}
+	var compressed, uncompressed int64
+	for _, su := range tu.Streams {
+		if su != want[su.Stream] {
+			t.Errorf("measureTask() stream usage = %+v, want %+v", su, want[su.Stream])
+		}
+		compressed += su.Compressed
+		uncompressed += su.Uncompressed
+	}
+	if tu.Compressed != compressed || tu.Uncompressed != uncompressed {
+		t.Errorf("measureTask() task usage = %v/%v, want the sum of the streams %v/%v",
+			tu.Compressed, tu.Uncompressed, compressed, uncompressed)
+	}
+}
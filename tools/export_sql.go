@@ -0,0 +1,231 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlSchema creates the tables of the SQL export. Times are UTC strings in the format SQLite date and time
+// functions understand, unknown times are NULL.
+const sqlSchema = `DROP TABLE IF EXISTS error_signature_tasks;
+DROP TABLE IF EXISTS error_signatures;
+DROP TABLE IF EXISTS analyzer_errors;
+DROP TABLE IF EXISTS finding_evidence;
+DROP TABLE IF EXISTS finding_tasks;
+DROP TABLE IF EXISTS findings;
+DROP TABLE IF EXISTS log_files;
+DROP TABLE IF EXISTS task_states;
+DROP TABLE IF EXISTS tasks;
+CREATE TABLE tasks (
+  dir_name TEXT PRIMARY KEY,
+  id TEXT NOT NULL,
+  name TEXT NOT NULL,
+  pod TEXT NOT NULL,
+  pod_type TEXT NOT NULL,
+  state TEXT NOT NULL,
+  started TEXT,
+  ended TEXT,
+  has_logs INTEGER NOT NULL,
+  agent TEXT,
+  agent_id TEXT,
+  framework_id TEXT,
+  container TEXT,
+  reason TEXT,
+  message TEXT,
+  cpus REAL,
+  mem REAL,
+  disk REAL
+);
+CREATE TABLE task_states (
+  dir_name TEXT NOT NULL REFERENCES tasks (dir_name),
+  state TEXT NOT NULL,
+  time TEXT NOT NULL
+);
+CREATE TABLE log_files (
+  dir_name TEXT NOT NULL REFERENCES tasks (dir_name),
+  stream TEXT NOT NULL,
+  -- Rotations are numbered from 0, the oldest first.
+  rotation INTEGER NOT NULL,
+  path TEXT NOT NULL,
+  size INTEGER NOT NULL
+);
+-- Findings are the issues found by the analyzers, ranked by severity.
+CREATE TABLE findings (
+  id INTEGER PRIMARY KEY,
+  analyzer TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  title TEXT NOT NULL,
+  remediation TEXT NOT NULL
+);
+CREATE TABLE finding_tasks (
+  finding_id INTEGER NOT NULL REFERENCES findings (id),
+  dir_name TEXT NOT NULL REFERENCES tasks (dir_name)
+);
+CREATE TABLE finding_evidence (
+  finding_id INTEGER NOT NULL REFERENCES findings (id),
+  position INTEGER NOT NULL,
+  text TEXT NOT NULL
+);
+CREATE TABLE analyzer_errors (
+  analyzer TEXT NOT NULL,
+  error TEXT NOT NULL
+);
+CREATE TABLE error_signatures (
+  id INTEGER PRIMARY KEY,
+  template TEXT NOT NULL,
+  count INTEGER NOT NULL,
+  example TEXT NOT NULL,
+  first TEXT,
+  last TEXT
+);
+CREATE TABLE error_signature_tasks (
+  signature_id INTEGER NOT NULL REFERENCES error_signatures (id),
+  dir_name TEXT NOT NULL REFERENCES tasks (dir_name)
+);
+`
+
+// SQLOptions select the optional parts of the SQL export. The tables are always created.
+type SQLOptions struct {
+	// Findings runs all the analyzers and exports their issues.
+	Findings bool
+	// Signatures mines the error signatures of the task logs.
+	Signatures bool
+}
+
+// WriteSQL writes the tasks, their state transitions, log files and, depending on the options, analyzer
+// findings and error signatures as SQL statements which create and fill the tables. The dump is
+// SQLite-compatible and uses only standard SQL types, so it can be loaded into other databases too.
+func WriteSQL(w io.Writer, bundlePath string, tasks []Task, opts SQLOptions) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("BEGIN TRANSACTION;\n")
+	_, _ = bw.WriteString(sqlSchema)
+	for _, t := range tasks {
+		rt, m := newReportTask(t), t.mesos()
+		writeSQLInsert(bw, "tasks", rt.DirName, rt.ID, rt.Name, rt.Pod, rt.PodType, rt.State, sqlTime(rt.Started),
+			sqlTime(rt.Ended), t.HasLogs, sqlNullString(m.AgentHost), sqlNullString(m.AgentID),
+			sqlNullString(m.FrameworkID), sqlNullString(m.Container), sqlNullString(m.Reason),
+			sqlNullString(m.Message), sqlResource(m, "cpus"), sqlResource(m, "mem"), sqlResource(m, "disk"))
+		for _, s := range []struct {
+			name string
+			t    time.Time
+		}{
+			{StateStarting, t.Staring},
+			{StateRunning, t.Running},
+			{StateKilled, t.Killed},
+			{StateFailed, t.Failed},
+		} {
+			if !s.t.IsZero() {
+				writeSQLInsert(bw, "task_states", t.DirName, s.name, sqlTime(s.t))
+			}
+		}
+		for _, stream := range LogStreamNames() {
+			paths, err := TaskLogFiles(t, stream)
+			if err != nil {
+				return err
+			}
+			for i, path := range paths {
+				info, err := os.Stat(path)
+				if err != nil {
+					return fmt.Errorf("cannot read log file: %v", err)
+				}
+				rel, err := filepath.Rel(bundlePath, path)
+				if err != nil {
+					return err
+				}
+				writeSQLInsert(bw, "log_files", t.DirName, stream, i, filepath.ToSlash(rel), info.Size())
+			}
+		}
+	}
+	if opts.Findings {
+		analysis := RunAnalyzers(Bundle{Path: bundlePath, Tasks: tasks}, Analyzers())
+		for i, issue := range analysis.Issues {
+			writeSQLInsert(bw, "findings", i+1, issue.Analyzer, issue.Severity, issue.Title, issue.Remediation)
+			for _, dirName := range issue.Tasks {
+				writeSQLInsert(bw, "finding_tasks", i+1, dirName)
+			}
+			for j, evidence := range issue.Evidence {
+				writeSQLInsert(bw, "finding_evidence", i+1, j, evidence)
+			}
+		}
+		for _, e := range analysis.Errors {
+			writeSQLInsert(bw, "analyzer_errors", e.Analyzer, e.Error)
+		}
+	}
+	if opts.Signatures {
+		signatures, err := MineErrorSignatures(tasks)
+		if err != nil {
+			return err
+		}
+		for i, s := range signatures {
+			writeSQLInsert(bw, "error_signatures", i+1, s.Template, s.Count, s.Example, sqlTime(s.First),
+				sqlTime(s.Last))
+			for _, source := range s.Sources {
+				writeSQLInsert(bw, "error_signature_tasks", i+1, source)
+			}
+		}
+	}
+	_, _ = bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// sqlNull is written as NULL.
+type sqlNull struct{}
+
+func sqlTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return sqlNull{}
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+func sqlNullString(s string) interface{} {
+	if s == "" {
+		return sqlNull{}
+	}
+	return s
+}
+
+func sqlResource(m MesosTask, name string) interface{} {
+	v, ok := m.Resources[name]
+	if !ok {
+		return sqlNull{}
+	}
+	return v
+}
+
+func writeSQLInsert(w *bufio.Writer, table string, values ...interface{}) {
+	literals := make([]string, 0, len(values))
+	for _, v := range values {
+		literals = append(literals, sqlLiteral(v))
+	}
+	_, _ = fmt.Fprintf(w, "INSERT INTO %v VALUES (%v);\n", table, strings.Join(literals, ", "))
+}
+
+// sqlLiteral returns the SQL literal of the value. Strings are quoted with single quotes which are doubled
+// inside the string, so log lines can be inserted as they are.
+func sqlLiteral(v interface{}) string {
+	switch v := v.(type) {
+	case sqlNull:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	}
+	panic(fmt.Sprintf("unsupported SQL value %T", v))
+}
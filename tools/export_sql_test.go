@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_sqlLiteral(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{sqlNull{}, "NULL"},
+		{true, "1"},
+		{false, "0"},
+		{42, "42"},
+		{int64(7), "7"},
+		{0.5, "0.5"},
+		{"it's", "'it''s'"},
+		{"", "''"},
+	}
+	for _, tt := range tests {
+		if got := sqlLiteral(tt.v); got != tt.want {
+			t.Errorf("sqlLiteral(%#v) = %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestWriteSQL(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task, err := parseTaskDirName("starting_20200416T110149-failed_20200416T114052__kafka-2-broker__06e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.DirNameAbsolute = filepath.Join(dir, DirNameTasks, task.DirName)
+	task.HasLogs = true
+	task.Mesos = &MesosTask{AgentHost: "10.0.1.11", Resources: map[string]float64{"cpus": 0.5}}
+	writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{
+		"stdout.1": []byte("2020-04-16 11:01:50 INFO Starting\n"),
+		"stdout":   []byte("2020-04-16 11:40:51 ERROR Disk is full\n"),
+	})
+	var b bytes.Buffer
+	if err := WriteSQL(&b, dir, []Task{task}, SQLOptions{Findings: true, Signatures: true}); err != nil {
+		t.Fatal(err)
+	}
+	dump := b.String()
+	dirName := "'" + task.DirName + "'"
+	for _, want := range []string{
+		"INSERT INTO tasks VALUES (" + dirName + ", '06e1', 'kafka-2-broker', 'kafka-2', 'kafka', 'failed', " +
+			"'2020-04-16 11:01:49', '2020-04-16 11:40:52', 1, '10.0.1.11', NULL, NULL, NULL, NULL, NULL, 0.5, NULL, NULL);",
+		"INSERT INTO task_states VALUES (" + dirName + ", 'starting', '2020-04-16 11:01:49');",
+		"INSERT INTO task_states VALUES (" + dirName + ", 'failed', '2020-04-16 11:40:52');",
+		"INSERT INTO log_files VALUES (" + dirName + ", 'stdout', 0, 'tasks/" + task.DirName + "/stdout.1', 34);",
+		"INSERT INTO log_files VALUES (" + dirName + ", 'stdout', 1, 'tasks/" + task.DirName + "/stdout', 39);",
+		"INSERT INTO error_signatures VALUES (1, 'ERROR Disk is full', 1, '2020-04-16 11:40:51 ERROR Disk is full', " +
+			"'2020-04-16 11:40:51', '2020-04-16 11:40:51');",
+		"INSERT INTO error_signature_tasks VALUES (1, " + dirName + ");",
+		"COMMIT;",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("WriteSQL() output doesn't contain %v", want)
+		}
+	}
+	// Findings are ranked by severity, the failed task is one of the critical ones.
+	id := ""
+	for _, line := range strings.Split(dump, "\n") {
+		prefix := "INSERT INTO findings VALUES ("
+		if strings.HasPrefix(line, prefix) && strings.Contains(line, "'failed-tasks', 'critical', "+
+			"'The latest run of kafka-2-broker failed at 2020-04-16 11:40:52 +0000 UTC'") {
+			id = strings.SplitN(strings.TrimPrefix(line, prefix), ",", 2)[0]
+		}
+	}
+	if id == "" {
+		t.Fatalf("WriteSQL() output doesn't contain the failed task finding:\n%v", dump)
+	}
+	for _, want := range []string{
+		"INSERT INTO finding_tasks VALUES (" + id + ", " + dirName + ");",
+		"INSERT INTO finding_evidence VALUES (" + id + ", 0, 'stdout: 2020-04-16 11:40:51 ERROR Disk is full');",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("WriteSQL() output doesn't contain %v", want)
+		}
+	}
+}
+
+func TestWriteSQL_noFindings(t *testing.T) {
+	task, err := parseTaskDirName("starting_20200416T110149-failed_20200416T114052__kafka-2-broker__06e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := WriteSQL(&b, "", []Task{task}, SQLOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"findings", "finding_tasks", "finding_evidence", "error_signatures"} {
+		if strings.Contains(b.String(), "INSERT INTO "+table+" ") {
+			t.Errorf("WriteSQL() without findings and signatures fills %v", table)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+package tools
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ExtractManifestFileName is the name of the manifest file in the extracted bundle.
+const ExtractManifestFileName = "extract_manifest.json"
+
+// ExtractManifest describes what Extract included into the sub-bundle and what it omitted.
+type ExtractManifest struct {
+	Bundle string   `json:"bundle"`
+	Filter []string `json:"filter"`
+	// Since and Until are the time window of the cut logs, they are omitted if there is no limit.
+	Since *time.Time `json:"since,omitempty"`
+	Until *time.Time `json:"until,omitempty"`
+	// TopLevel are the files and directories from the bundle root which were included.
+	TopLevel        []string `json:"topLevel"`
+	OmittedTopLevel []string `json:"omittedTopLevel"`
+	Tasks           []string `json:"tasks"`
+	OmittedTasks    []string `json:"omittedTasks"`
+	// CutLogs are the log files which were cut to the time window.
+	CutLogs []string `json:"cutLogs,omitempty"`
+}
+
+// ExtractOptions define what Extract includes in addition to the tasks matching the filter.
+type ExtractOptions struct {
+	Filter TaskFilter
+	// Since and Until cut the task logs to the time window. Zero values mean no limit.
+	Since time.Time
+	Until time.Time
+	// Include are glob patterns of the top-level directories to include. Top-level files are always included.
+	Include []string
+}
+
+// Extract writes a sub-bundle with the top-level files of the bundle and the directories of the tasks
+// matching the filter. If outPath ends with .tar.gz or .tgz, the sub-bundle is written as an archive,
+// otherwise it is written to the outPath directory. If extraction fails, the partial output is removed.
+func Extract(bundlePath string, outPath string, opts ExtractOptions) (ExtractManifest, error) {
+	manifest := ExtractManifest{
+		Bundle: bundlePath,
+		Filter: opts.Filter.Conditions(),
+	}
+	if !opts.Since.IsZero() {
+		manifest.Since = &opts.Since
+	}
+	if !opts.Until.IsZero() {
+		manifest.Until = &opts.Until
+	}
+	tasks, err := FindTasks(bundlePath)
+	if err != nil {
+		return manifest, err
+	}
+	var sink extractSink
+	if strings.HasSuffix(outPath, ".tar.gz") || strings.HasSuffix(outPath, ".tgz") {
+		sink, err = newTarSink(outPath)
+	} else {
+		sink, err = newDirSink(outPath)
+	}
+	if err != nil {
+		return manifest, err
+	}
+	if err := extractTo(bundlePath, outPath, sink, tasks, opts, &manifest); err != nil {
+		if removeErr := sink.Remove(); removeErr != nil {
+			return manifest, fmt.Errorf("%v; cannot remove the partial output: %v", err, removeErr)
+		}
+		return manifest, err
+	}
+	return manifest, nil
+}
+
+// extractTo writes the sub-bundle to the sink and closes it.
+func extractTo(bundlePath string, outPath string, sink extractSink, tasks []Task, opts ExtractOptions,
+	manifest *ExtractManifest) error {
+	if err := extractTopLevel(bundlePath, outPath, sink, opts.Include, manifest); err != nil {
+		return err
+	}
+	window := LogWindow{Since: opts.Since, Until: opts.Until}
+	for _, task := range tasks {
+		if !opts.Filter.Match(task) {
+			manifest.OmittedTasks = append(manifest.OmittedTasks, task.DirName)
+			continue
+		}
+		manifest.Tasks = append(manifest.Tasks, task.DirName)
+		if err := extractTask(task, sink, window, manifest); err != nil {
+			return err
+		}
+	}
+	w, err := sink.Create(ExtractManifestFileName)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return sink.Close()
+}
+
+func extractTopLevel(bundlePath string, outPath string, sink extractSink, include []string,
+	manifest *ExtractManifest) error {
+	infos, err := ioutil.ReadDir(bundlePath)
+	if err != nil {
+		return fmt.Errorf("cannot read bundle directory: %v", err)
+	}
+	absOut, err := filepath.Abs(outPath)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		path := filepath.Join(bundlePath, name)
+		absPath, _ := filepath.Abs(path)
+		switch {
+		case name == DirNameTasks || absPath == absOut:
+			continue
+		case info.Mode().IsRegular():
+			if err := sink.AddFile(name, path, info); err != nil {
+				return err
+			}
+		case info.IsDir() && matchesAny(name, include):
+			if err := addTree(sink, path, name); err != nil {
+				return err
+			}
+		default:
+			manifest.OmittedTopLevel = append(manifest.OmittedTopLevel, name)
+			continue
+		}
+		manifest.TopLevel = append(manifest.TopLevel, name)
+	}
+	return nil
+}
+
+func extractTask(task Task, sink extractSink, window LogWindow, manifest *ExtractManifest) error {
+	if !window.hasTimeLimits() {
+		return addTree(sink, task.DirNameAbsolute, filepath.Join(DirNameTasks, task.DirName))
+	}
+	streamFiles, err := taskStreamFiles(task)
+	if err != nil {
+		return err
+	}
+	return filepath.Walk(task.DirNameAbsolute, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filepath.Dir(task.DirNameAbsolute), path)
+		if err != nil {
+			return err
+		}
+		name := filepath.Join(DirNameTasks, rel)
+		if info.IsDir() {
+			return sink.AddDir(name, info)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if _, ok := streamFiles[path]; !ok {
+			return sink.AddFile(name, path, info)
+		}
+		manifest.CutLogs = append(manifest.CutLogs, name)
+		return cutLog(sink, name, path, window)
+	})
+}
+
+// cutLog writes the lines of the log file which are inside the window, compressing them if the file is compressed.
+func cutLog(sink extractSink, name string, path string, window LogWindow) error {
+	r, err := fileReader(path)
+	if err != nil {
+		return err
+	}
+	defer closeCloser(r)
+	w, err := sink.Create(name)
+	if err != nil {
+		return err
+	}
+	var out io.WriteCloser = w
+	if filepath.Ext(path) == ".gz" {
+		out = gzip.NewWriter(w)
+	}
+	if _, err := CopyLog(out, r, window); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("cannot cut log %v: %v", path, err)
+	}
+	if out != w {
+		if err := out.Close(); err != nil {
+			_ = w.Close()
+			return err
+		}
+	}
+	return w.Close()
+}
+
+func addTree(sink extractSink, src string, name string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return sink.AddDir(filepath.Join(name, rel), info)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return sink.AddFile(filepath.Join(name, rel), path, info)
+	})
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extractSink is a directory or an archive to which Extract writes files.
+type extractSink interface {
+	AddDir(name string, info os.FileInfo) error
+	// AddFile copies the file.
+	AddFile(name string, path string, info os.FileInfo) error
+	// Create returns a writer for a new file, the file is added when the writer is closed.
+	Create(name string) (io.WriteCloser, error)
+	Close() error
+	// Remove closes the sink and removes everything written to it.
+	Remove() error
+}
+
+type dirSink struct {
+	dir string
+	// created is true if the directory didn't exist before.
+	created bool
+}
+
+func newDirSink(dir string) (*dirSink, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err == nil && len(infos) != 0 {
+		return nil, fmt.Errorf("output directory %v is not empty", dir)
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, fmt.Errorf("cannot create output directory: %v", err)
+	}
+	return &dirSink{dir: dir, created: os.IsNotExist(err)}, nil
+}
+
+func (s *dirSink) AddDir(name string, _ os.FileInfo) error {
+	return os.MkdirAll(filepath.Join(s.dir, name), 0777)
+}
+
+func (s *dirSink) AddFile(name string, path string, _ os.FileInfo) error {
+	return copyFile(path, filepath.Join(s.dir, name))
+}
+
+func (s *dirSink) Create(name string) (io.WriteCloser, error) {
+	return os.Create(filepath.Join(s.dir, name))
+}
+
+func (s *dirSink) Close() error {
+	return nil
+}
+
+// Remove removes the directory if it was created by the sink, otherwise it removes the directory content,
+// because the directory was empty.
+func (s *dirSink) Remove() error {
+	if s.created {
+		return os.RemoveAll(s.dir)
+	}
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if err := os.RemoveAll(filepath.Join(s.dir, info.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type tarSink struct {
+	f    *os.File
+	gzw  *gzip.Writer
+	tw   *tar.Writer
+	root string
+}
+
+// newTarSink creates the archive with a single top-level directory named after the archive.
+func newTarSink(path string) (*tarSink, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create archive: %v", err)
+	}
+	gzw := gzip.NewWriter(f)
+	root := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".tgz"), ".tar.gz")
+	return &tarSink{f: f, gzw: gzw, tw: tar.NewWriter(gzw), root: root}, nil
+}
+
+func (s *tarSink) AddDir(name string, info os.FileInfo) error {
+	h, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	h.Name = filepath.ToSlash(filepath.Join(s.root, name)) + "/"
+	return s.tw.WriteHeader(h)
+}
+
+func (s *tarSink) AddFile(name string, path string, info os.FileInfo) error {
+	h, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+	h.Name = filepath.ToSlash(filepath.Join(s.root, name))
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer closeCloser(f)
+	if err := s.tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err = io.CopyN(s.tw, f, h.Size)
+	return err
+}
+
+// Create writes the file to a temporary file first, because the size has to be known before
+// the file is added to the archive.
+func (s *tarSink) Create(name string) (io.WriteCloser, error) {
+	tmp, err := ioutil.TempFile("", "sbun-extract")
+	if err != nil {
+		return nil, err
+	}
+	return &tarTempFile{File: tmp, sink: s, name: name}, nil
+}
+
+func (s *tarSink) Close() error {
+	errs := make([]string, 0)
+	for _, c := range []io.Closer{s.tw, s.gzw, s.f} {
+		if err := c.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("cannot close archive: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func (s *tarSink) Remove() error {
+	_ = s.Close()
+	return os.Remove(s.f.Name())
+}
+
+type tarTempFile struct {
+	*os.File
+	sink *tarSink
+	name string
+}
+
+func (t *tarTempFile) Close() error {
+	defer func() { _ = os.Remove(t.File.Name()) }()
+	if err := t.File.Close(); err != nil {
+		return err
+	}
+	info, err := os.Stat(t.File.Name())
+	if err != nil {
+		return err
+	}
+	return t.sink.AddFile(t.name, t.File.Name(), info)
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	bundle := filepath.Join(dir, "bundle")
+	broker0 := "starting_20200416T110000__kafka-0-broker__kafka-0-broker__a"
+	broker1 := "starting_20200416T110000__kafka-1-broker__kafka-1-broker__b"
+	writeTestFiles(t, bundle, map[string][]byte{
+		"service.json":         []byte("{}\n"),
+		"scheduler/plans.json": []byte("{}\n"),
+		"mesos/state.json":     []byte("{}\n"),
+		"tasks/" + broker0 + "/stdout.1.gz": gzipMember(t,
+			"2020-04-16 11:00:00 rotated\n2020-04-16 11:05:00 rotated in window\n"),
+		"tasks/" + broker0 + "/stdout": []byte(
+			"2020-04-16 11:06:00 in window\n  stack trace\n2020-04-16 11:20:00 late\n"),
+		"tasks/" + broker0 + "/kafka/server.properties": []byte("broker.id=0\n"),
+		"tasks/" + broker1 + "/stdout":                  []byte("2020-04-16 11:06:00 other broker\n"),
+	})
+	filter, err := ParseTaskFilter([]string{"name=kafka-0-broker"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	since := time.Date(2020, 4, 16, 11, 5, 0, 0, time.UTC)
+	until := time.Date(2020, 4, 16, 11, 10, 0, 0, time.UTC)
+	out := filepath.Join(dir, "sub.tar.gz")
+	opts := ExtractOptions{Filter: filter, Since: since, Until: until, Include: []string{"sched*"}}
+	if _, err := Extract(bundle, out, opts); err != nil {
+		t.Fatal(err)
+	}
+	files := readTarGz(t, out)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	for _, want := range []string{
+		"sub/service.json",
+		"sub/scheduler/plans.json",
+		"sub/tasks/" + broker0 + "/kafka/server.properties",
+		"sub/" + ExtractManifestFileName,
+	} {
+		if _, ok := files[want]; !ok {
+			t.Errorf("archive has no %v, files: %v", want, names)
+		}
+	}
+	for _, name := range names {
+		if strings.Contains(name, "mesos") || strings.Contains(name, broker1) {
+			t.Errorf("archive has omitted file %v", name)
+		}
+	}
+	if got, want := files["sub/tasks/"+broker0+"/stdout"], "2020-04-16 11:06:00 in window\n  stack trace\n"; got != want {
+		t.Errorf("cut stdout = %q, want %q", got, want)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(files["sub/tasks/"+broker0+"/stdout.1.gz"]))
+	if err != nil {
+		t.Fatalf("cut rotation is not compressed: %v", err)
+	}
+	rotation, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(rotation), "2020-04-16 11:05:00 rotated in window\n"; got != want {
+		t.Errorf("cut stdout.1.gz = %q, want %q", got, want)
+	}
+	manifest := ExtractManifest{}
+	if err := json.Unmarshal([]byte(files["sub/"+ExtractManifestFileName]), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	want := ExtractManifest{
+		Bundle:          bundle,
+		Filter:          []string{"name=kafka-0-broker"},
+		Since:           &since,
+		Until:           &until,
+		TopLevel:        []string{"scheduler", "service.json"},
+		OmittedTopLevel: []string{"mesos"},
+		Tasks:           []string{broker0},
+		OmittedTasks:    []string{broker1},
+		CutLogs: []string{
+			filepath.Join(DirNameTasks, broker0, "stdout"),
+			filepath.Join(DirNameTasks, broker0, "stdout.1.gz"),
+		},
+	}
+	if !reflect.DeepEqual(manifest, want) {
+		t.Errorf("manifest = %+v, want %+v", manifest, want)
+	}
+}
+
+func TestExtract_noTimeWindow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	bundle := filepath.Join(dir, "bundle")
+	task := "starting_20200416T110000__kafka-0-broker__kafka-0-broker__a"
+	writeTestFiles(t, bundle, map[string][]byte{"tasks/" + task + "/stdout": []byte("no timestamps\n")})
+	out := filepath.Join(dir, "sub")
+	if _, err := Extract(bundle, out, ExtractOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(out, ExtractManifestFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "since") || strings.Contains(string(data), "until") {
+		t.Errorf("manifest has time limits: %s", data)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(out, "tasks", task, "stdout")); string(data) != "no timestamps\n" {
+		t.Errorf("stdout = %q, %v, want it copied", data, err)
+	}
+}
+
+func TestExtractSink_Remove(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	existing := filepath.Join(dir, "existing")
+	if err := os.Mkdir(existing, 0777); err != nil {
+		t.Fatal(err)
+	}
+	tarSink, err := newTarSink(filepath.Join(dir, "sub.tar.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	newDir, err := newDirSink(filepath.Join(dir, "new"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	existingDir, err := newDirSink(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sink := range []extractSink{tarSink, newDir, existingDir} {
+		w, err := sink.Create("file")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, "partial"); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := sink.Remove(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Name() != "existing" {
+		t.Errorf("Remove() left %v files in the output directory, want only the existing directory", len(infos))
+	}
+	if infos, _ := ioutil.ReadDir(existing); len(infos) != 0 {
+		t.Errorf("Remove() left %v files in the existing directory", len(infos))
+	}
+}
+
+// readTarGz returns the content of the regular files in the archive by name.
+func readTarGz(t *testing.T, path string) map[string]string {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer closeCloser(f)
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	files := make(map[string]string)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[h.Name] = string(data)
+	}
+}
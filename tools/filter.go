@@ -0,0 +1,195 @@
+package tools
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// taskFields are the task fields which can be used in filters and as grouping keys.
+var taskFields = map[string]func(t Task) string{
+	"name":     func(t Task) string { return t.Name },
+	"id":       func(t Task) string { return t.ID },
+	"dir":      func(t Task) string { return t.DirName },
+	"pod":      func(t Task) string { return t.PodInstance() },
+	"pod-type": func(t Task) string { return t.PodType() },
+	"state":    func(t Task) string { return t.State() },
+	"has-logs": func(t Task) string { return strconv.FormatBool(t.HasLogs) },
+	"day":      func(t Task) string { return formatDay(t.Started()) },
+	"started":  func(t Task) string { return formatTaskTime(t.Started()) },
+	// Fields from the Mesos state, empty if the task is not in the state.
+	"agent":        func(t Task) string { return t.mesos().AgentHost },
+	"agent-id":     func(t Task) string { return t.mesos().AgentID },
+	"framework-id": func(t Task) string { return t.mesos().FrameworkID },
+	"resources":    func(t Task) string { return t.mesos().FormatResources() },
+	"cpus":         func(t Task) string { return t.mesos().Resource("cpus") },
+	"mem":          func(t Task) string { return t.mesos().Resource("mem") },
+	"disk":         func(t Task) string { return t.mesos().Resource("disk") },
+	"container":    func(t Task) string { return t.mesos().Container },
+	"reason":       func(t Task) string { return t.mesos().Reason },
+	"message":      func(t Task) string { return t.mesos().Message },
+}
+
+// TaskFieldNames returns the names of the fields which can be used in filters and as grouping keys.
+func TaskFieldNames() []string {
+	names := make([]string, 0, len(taskFields))
+	for name := range taskFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TaskField returns the value of the task field.
+func TaskField(t Task, field string) (string, error) {
+	f, ok := taskFields[field]
+	if !ok {
+		return "", fmt.Errorf("unknown task field %q, known fields: %v", field, strings.Join(TaskFieldNames(), ", "))
+	}
+	return f(t), nil
+}
+
+type condition struct {
+	field string
+	op    string
+	value string
+	// t is the value parsed as time for the "<" and ">" operators.
+	t time.Time
+}
+
+// TaskFilter selects tasks which match all its conditions.
+type TaskFilter struct {
+	conditions []condition
+	raw        []string
+}
+
+// ParseTaskFilter parses conditions like "state=failed", "pod-type!=kafka", "name=kafka-*-broker",
+// "started>2020-04-16T11:00". The = and != operators support glob patterns, the < and > operators
+// compare the started field with a time.
+func ParseTaskFilter(conditions []string) (TaskFilter, error) {
+	filter := TaskFilter{}
+	for _, s := range conditions {
+		c, err := parseCondition(s)
+		if err != nil {
+			return filter, err
+		}
+		filter.conditions = append(filter.conditions, c)
+	}
+	filter.raw = append([]string{}, conditions...)
+	return filter, nil
+}
+
+// Conditions returns the conditions the filter was parsed from.
+func (f TaskFilter) Conditions() []string {
+	return f.raw
+}
+
+func parseCondition(s string) (condition, error) {
+	c := condition{}
+	i := strings.IndexAny(s, "=!<>")
+	if i <= 0 {
+		return c, fmt.Errorf("condition %q is not in the <field><operator><value> format", s)
+	}
+	c.field = s[:i]
+	for _, op := range []string{"!=", "=", "<", ">"} {
+		if strings.HasPrefix(s[i:], op) {
+			c.op, c.value = op, s[i+len(op):]
+			break
+		}
+	}
+	if c.op == "" {
+		return c, fmt.Errorf("unknown operator in condition %q", s)
+	}
+	if _, ok := taskFields[c.field]; !ok {
+		return c, fmt.Errorf("unknown task field %q in condition %q, known fields: %v",
+			c.field, s, strings.Join(TaskFieldNames(), ", "))
+	}
+	switch c.op {
+	case "=", "!=":
+		if _, err := path.Match(c.value, ""); err != nil {
+			return c, fmt.Errorf("invalid pattern in condition %q: %v", s, err)
+		}
+	case "<", ">":
+		if c.field != "started" {
+			return c, fmt.Errorf("operator %v can be used only with the started field: %q", c.op, s)
+		}
+		t, err := ParseTimeFlag(c.value)
+		if err != nil {
+			return c, fmt.Errorf("cannot parse time in condition %q: %v", s, err)
+		}
+		c.t = t
+	}
+	return c, nil
+}
+
+func (c condition) match(t Task) bool {
+	switch c.op {
+	case "<":
+		return t.Started().Before(c.t)
+	case ">":
+		return t.Started().After(c.t)
+	}
+	matched, _ := path.Match(c.value, taskFields[c.field](t))
+	return matched == (c.op == "=")
+}
+
+// Match reports whether the task matches all the conditions of the filter.
+func (f TaskFilter) Match(t Task) bool {
+	for _, c := range f.conditions {
+		if !c.match(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the tasks matching the filter.
+func (f TaskFilter) Filter(tasks []Task) []Task {
+	filtered := make([]Task, 0, len(tasks))
+	for _, t := range tasks {
+		if f.Match(t) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
+
+// LatestRunPerInstance returns the latest task of every pod instance and every task which
+// doesn't belong to a pod instance, e.g., the latest scheduler task.
+func LatestRunPerInstance(tasks []Task) []Task {
+	latest := make(map[string]Task)
+	keys := make([]string, 0)
+	for _, t := range tasks {
+		key := t.Name
+		l, ok := latest[key]
+		if !ok {
+			keys = append(keys, key)
+		}
+		if !ok || t.Started().After(l.Started()) {
+			latest[key] = t
+		}
+	}
+	result := make([]Task, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, latest[key])
+	}
+	return result
+}
+
+func formatDay(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("2006-01-02")
+}
+
+// formatTaskTime formats the time the way it is formatted in the task directory names.
+func formatTaskTime(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("20060102T150405")
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"testing"
+)
+
+func TestTaskFilter_Match(t *testing.T) {
+	task, err := parseTaskDirName("starting_20200416T110149-running_20200416T112050-killed_20200416T114052" +
+		"__kafka-2-broker__06e119a6-b6bb-4dae-8229-799cdf54c752")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		conditions []string
+		want       bool
+	}{
+		{"matches without conditions", nil, true},
+		{"matches equal values", []string{"state=killed", "pod=kafka-2"}, true},
+		{"matches glob patterns", []string{"name=kafka-*-broker"}, true},
+		{"does not match different values", []string{"pod-type=zookeeper"}, false},
+		{"negates with !=", []string{"pod-type!=kafka"}, false},
+		{"requires all conditions to match", []string{"state=killed", "has-logs=true"}, false},
+		{"compares start time", []string{"started>2020-04-16T11:00", "started<2020-04-17"}, true},
+		{"matches day", []string{"day=2020-04-16"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseTaskFilter(tt.conditions)
+			if err != nil {
+				t.Fatalf("ParseTaskFilter() error = %v", err)
+			}
+			if got := f.Match(task); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTaskFilter_errors(t *testing.T) {
+	for _, condition := range []string{"state", "=failed", "color=red", "state>2020-04-16", "started>yesterday"} {
+		if _, err := ParseTaskFilter([]string{condition}); err == nil {
+			t.Errorf("ParseTaskFilter(%q) error = nil, want an error", condition)
+		}
+	}
+}
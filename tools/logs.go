@@ -0,0 +1,232 @@
+package tools
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+)
+
+// TaskLogFiles returns paths to the log files of the given stream in the order they were written,
+// i.e., the oldest rotation first. If the rotations were already concatenated with Concat,
+// it returns the concatenated file. Directories of the stream are checked in the configured order,
+// the first directory with the stream files is used.
+func TaskLogFiles(task Task, stream string) ([]string, error) {
+	s, err := findLogStream(stream)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := s.taskDirs(task.DirNameAbsolute)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		paths, allPath, err := s.files(dir)
+		if err != nil {
+			return nil, err
+		}
+		if allPath != "" {
+			return []string{allPath}, nil
+		}
+		if len(paths) != 0 {
+			return paths, nil
+		}
+	}
+	return nil, nil
+}
+
+// taskDirs returns existing directories matching the stream directory patterns.
+func (s LogStream) taskDirs(taskDir string) ([]string, error) {
+	dirs := make([]string, 0, len(s.Dirs))
+	seen := make(map[string]bool)
+	for _, pattern := range s.Dirs {
+		matches, err := filepath.Glob(filepath.Join(taskDir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid directory pattern %q of the log stream %q: %v", pattern, s.Name, err)
+		}
+		for _, dir := range matches {
+			if seen[dir] || !dirExists(dir) {
+				continue
+			}
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// files returns the stream rotations in the directory, the oldest first, and the path
+// to the concatenated stream file if it exists.
+func (s LogStream) files(dir string) ([]string, string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read dir %v: %v", dir, err)
+	}
+	paths := make([]string, 0, len(infos))
+	allPath := ""
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		name := info.Name()
+		if name == s.AllFileName || name == s.AllFileName+".gz" {
+			allPath = filepath.Join(dir, name)
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	paths = filterPathsByFileName(paths, s.FileName)
+	sortPathsByFileName(paths, s.FileName)
+	return paths, allPath, nil
+}
+
+// OpenTaskLog returns a reader which reads all the rotations of the task log stream as a single file.
+func OpenTaskLog(task Task, stream string) (io.ReadCloser, error) {
+	paths, err := TaskLogFiles(task, stream)
+	if err != nil {
+		return nil, err
+	}
+	return newMultiFileReader(paths), nil
+}
+
+// multiFileReader reads files one after another, it opens the next file only when the previous one is read.
+// Files which cannot be opened are skipped and recorded, see LogDamages.
+type multiFileReader struct {
+	paths   []string
+	current io.ReadCloser
+}
+
+func newMultiFileReader(paths []string) *multiFileReader {
+	return &multiFileReader{paths: paths}
+}
+
+func (m *multiFileReader) Read(p []byte) (int, error) {
+	for {
+		if m.current == nil {
+			if len(m.paths) == 0 {
+				return 0, io.EOF
+			}
+			r, err := fileReader(m.paths[0])
+			if err != nil {
+				recordDamage(LogDamage{Path: m.paths[0], Skipped: -1, Error: err.Error()})
+				m.paths = m.paths[1:]
+				continue
+			}
+			m.current = r
+			m.paths = m.paths[1:]
+		}
+		n, err := m.current.Read(p)
+		if err == io.EOF {
+			closeCloser(m.current)
+			m.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (m *multiFileReader) Close() error {
+	m.paths = nil
+	if m.current == nil {
+		return nil
+	}
+	err := m.current.Close()
+	m.current = nil
+	return err
+}
+
+// LogWindow selects lines of a log. Zero values mean no limit.
+type LogWindow struct {
+	Since time.Time
+	Until time.Time
+	// Head is the number of lines to take from the beginning of the window.
+	Head int
+	// Tail is the number of lines to take from the end of the window.
+	Tail int
+}
+
+func (lw LogWindow) hasTimeLimits() bool {
+	return !lw.Since.IsZero() || !lw.Until.IsZero()
+}
+
+// contains reports whether a line with the timestamp t is inside the time window. Lines with unknown
+// time are inside the window, since it cannot be applied to them.
+func (lw LogWindow) contains(t time.Time) bool {
+	if !lw.hasTimeLimits() || t.IsZero() {
+		return true
+	}
+	return !t.Before(lw.Since) && (lw.Until.IsZero() || t.Before(lw.Until))
+}
+
+// CopyLog copies the lines of the log which are in the window. Lines without a timestamp,
+// like stack trace lines, get the timestamp of the closest preceding line. Lines before the first
+// timestamped line are copied regardless of the time limits; CopyLog returns the number of such lines,
+// so that the caller can warn that the time limits were not applied to them.
+func CopyLog(w io.Writer, r io.Reader, window LogWindow) (int, error) {
+	type logLine struct {
+		text    string
+		untimed bool
+	}
+	bw := bufio.NewWriter(w)
+	var last time.Time
+	var tail []logLine
+	written, untimed := 0, 0
+	write := func(l logLine) error {
+		if _, err := fmt.Fprintln(bw, l.text); err != nil {
+			return err
+		}
+		written++
+		if l.untimed {
+			untimed++
+		}
+		return nil
+	}
+	errStop := errors.New("stop")
+	err := forEachLineErr(r, func(line string) error {
+		if t, _, ok := ParseLineTime(line); ok {
+			last = t
+		}
+		if !window.contains(last) {
+			return nil
+		}
+		l := logLine{line, last.IsZero() && window.hasTimeLimits()}
+		if window.Tail > 0 {
+			tail = append(tail, l)
+			if len(tail) > window.Tail {
+				tail = tail[1:]
+			}
+			return nil
+		}
+		if err := write(l); err != nil {
+			return err
+		}
+		if window.Head > 0 && written >= window.Head {
+			return errStop
+		}
+		return nil
+	})
+	if err != nil && err != errStop {
+		return untimed, err
+	}
+	// If both Head and Tail are set, the first Head lines of the tail are written.
+	for _, l := range tail {
+		if window.Head > 0 && written >= window.Head {
+			break
+		}
+		if err := write(l); err != nil {
+			return untimed, err
+		}
+	}
+	return untimed, bw.Flush()
+}
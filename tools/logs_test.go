@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCopyLog(t *testing.T) {
+	log := strings.Join([]string{
+		"2020-04-16 11:00:00 first",
+		"2020-04-16 11:01:00 second",
+		"  at stack.trace.Line",
+		"2020-04-16 11:02:00 third",
+		"2020-04-16 11:03:00 fourth",
+	}, "\n") + "\n"
+	noTimestamps := "no\ntimestamps\nat all\n"
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+	tests := []struct {
+		name        string
+		log         string
+		window      LogWindow
+		want        []string
+		wantUntimed int
+	}{
+		{
+			"copies the whole log without limits",
+			log,
+			LogWindow{},
+			[]string{"first", "second", "stack", "third", "fourth"},
+			0,
+		},
+		{
+			"copies the first lines",
+			log,
+			LogWindow{Head: 2},
+			[]string{"first", "second"},
+			0,
+		},
+		{
+			"copies the last lines",
+			log,
+			LogWindow{Tail: 2},
+			[]string{"third", "fourth"},
+			0,
+		},
+		{
+			"copies the first lines of the tail",
+			log,
+			LogWindow{Head: 2, Tail: 3},
+			[]string{"stack", "third"},
+			0,
+		},
+		{
+			"keeps lines without a timestamp with the preceding line",
+			log,
+			LogWindow{Since: at("2020-04-16 11:01:00"), Until: at("2020-04-16 11:03:00")},
+			[]string{"second", "stack", "third"},
+			0,
+		},
+		{
+			"drops lines without a timestamp with the preceding line",
+			log,
+			LogWindow{Since: at("2020-04-16 11:02:00")},
+			[]string{"third", "fourth"},
+			0,
+		},
+		{
+			"applies the time window before the tail",
+			log,
+			LogWindow{Until: at("2020-04-16 11:02:00"), Tail: 1},
+			[]string{"stack"},
+			0,
+		},
+		{
+			"copies logs without timestamps regardless of the time window",
+			noTimestamps,
+			LogWindow{Since: at("2020-04-16 11:02:00")},
+			[]string{"no", "timestamps", "at all"},
+			3,
+		},
+		{
+			"counts only the copied lines without a timestamp",
+			"untimed header\n" + log,
+			LogWindow{Since: at("2020-04-16 11:03:00"), Tail: 1},
+			[]string{"fourth"},
+			0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			untimed, err := CopyLog(&buf, strings.NewReader(tt.log), tt.window)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("CopyLog() copied %q, want lines containing %v", buf.String(), tt.want)
+			}
+			for i, line := range lines {
+				if !strings.Contains(line, tt.want[i]) {
+					t.Errorf("CopyLog() line %v = %q, want it to contain %q", i, line, tt.want[i])
+				}
+			}
+			if untimed != tt.wantUntimed {
+				t.Errorf("CopyLog() returned %v lines without a timestamp, want %v", untimed, tt.wantUntimed)
+			}
+		})
+	}
+}
+
+func TestFindTask(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2020, 4, 16, hour, 0, 0, 0, time.UTC)
+	}
+	tasks := []Task{
+		{ID: "kafka-0-broker__aaa1", Name: "kafka-0-broker", DirName: "dir-a", Staring: at(10)},
+		{ID: "kafka-0-broker__aaa2", Name: "kafka-0-broker", DirName: "dir-b", Staring: at(12)},
+		{ID: "kafka-0-broker__bbb", Name: "kafka-0-broker", DirName: "dir-c", Staring: at(11)},
+		{ID: "kafka-1-broker__ccc", Name: "kafka-1-broker", DirName: "dir-d", Staring: at(9)},
+	}
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr string
+	}{
+		{"finds a task by ID", "kafka-0-broker__bbb", "dir-c", ""},
+		{"finds a task by directory name", "dir-a", "dir-a", ""},
+		{"finds a task by a unique ID prefix", "kafka-1", "dir-d", ""},
+		{"finds the latest task by name", "kafka-0-broker", "dir-b", ""},
+		{"rejects an ambiguous ID prefix", "kafka-0-broker__aaa", "", "ambiguous"},
+		{"rejects an unknown task", "zookeeper", "", "cannot find"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindTask(tasks, tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FindTask() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.DirName != tt.want {
+				t.Errorf("FindTask() = %v, want %v", got.DirName, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MesosTask is what the Mesos state knows about a task.
+type MesosTask struct {
+	ID          string             `json:"id"`
+	AgentID     string             `json:"agentId"`
+	AgentHost   string             `json:"agentHost"`
+	FrameworkID string             `json:"frameworkId"`
+	Resources   map[string]float64 `json:"resources"`
+	// Container is the container type, e.g., MESOS or DOCKER.
+	Container string `json:"container"`
+	// Reason and Message are from the latest task status.
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+}
+
+// FormatResources returns the resources like "cpus:1 disk:5000 mem:2048".
+func (m MesosTask) FormatResources() string {
+	names := make([]string, 0, len(m.Resources))
+	for name := range m.Resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	tokens := make([]string, 0, len(names))
+	for _, name := range names {
+		tokens = append(tokens, name+":"+formatResource(m.Resources[name]))
+	}
+	return strings.Join(tokens, " ")
+}
+
+// Resource returns the amount of the resource, e.g., "cpus", or an empty string if it is unknown.
+func (m MesosTask) Resource(name string) string {
+	v, ok := m.Resources[name]
+	if !ok {
+		return ""
+	}
+	return formatResource(v)
+}
+
+func formatResource(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// mesosState is the part of the master or agent state JSON which is needed to find tasks. The master state
+// lists agents in "slaves", the agent state has its own ID and hostname at the top level.
+type mesosState struct {
+	ID                  string           `json:"id"`
+	Hostname            string           `json:"hostname"`
+	Slaves              []mesosAgent     `json:"slaves"`
+	Frameworks          []mesosFramework `json:"frameworks"`
+	CompletedFrameworks []mesosFramework `json:"completed_frameworks"`
+}
+
+type mesosAgent struct {
+	ID       string `json:"id"`
+	Hostname string `json:"hostname"`
+}
+
+type mesosFramework struct {
+	ID                 string          `json:"id"`
+	Tasks              []mesosTask     `json:"tasks"`
+	CompletedTasks     []mesosTask     `json:"completed_tasks"`
+	UnreachableTasks   []mesosTask     `json:"unreachable_tasks"`
+	Executors          []mesosExecutor `json:"executors"`
+	CompletedExecutors []mesosExecutor `json:"completed_executors"`
+}
+
+type mesosExecutor struct {
+	Tasks          []mesosTask `json:"tasks"`
+	QueuedTasks    []mesosTask `json:"queued_tasks"`
+	CompletedTasks []mesosTask `json:"completed_tasks"`
+}
+
+type mesosTask struct {
+	ID          string                     `json:"id"`
+	FrameworkID string                     `json:"framework_id"`
+	SlaveID     string                     `json:"slave_id"`
+	Resources   map[string]json.RawMessage `json:"resources"`
+	Statuses    []struct {
+		Timestamp float64 `json:"timestamp"`
+		Reason    string  `json:"reason"`
+		Message   string  `json:"message"`
+	} `json:"statuses"`
+	Container struct {
+		Type string `json:"type"`
+	} `json:"container"`
+}
+
+// FindMesosTasks parses the Mesos master and agent state files in the bundle and returns the tasks by
+// Mesos task ID. State files are JSON files with "state" in their name outside of the task directories,
+// views and extracted bundles.
+// The master state takes precedence, agent states only fill the fields which are unknown to the master,
+// e.g., the container type. Agents may report a hostname which differs from the one registered in the master.
+func FindMesosTasks(bundlePath string) (map[string]MesosTask, error) {
+	masterTasks := make(map[string]MesosTask)
+	agentTasks := make(map[string]MesosTask)
+	err := filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot walk into path %v: %v\n", path, err)
+			return nil
+		}
+		if info.IsDir() {
+			if path == filepath.Join(bundlePath, DirNameTasks) || path != bundlePath && isGeneratedDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isMesosStateFile(info.Name()) {
+			return nil
+		}
+		if err := parseMesosState(path, masterTasks, agentTasks); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot parse Mesos state file %v: %v\n", path, err)
+		}
+		return nil
+	})
+	for id, m := range agentTasks {
+		masterTasks[id] = mergeMesosTasks(masterTasks[id], m)
+	}
+	return masterTasks, err
+}
+
+func isMesosStateFile(name string) bool {
+	name = strings.ToLower(name)
+	return filepath.Ext(name) == ".json" && strings.Contains(name, "state")
+}
+
+// parseMesosState adds the tasks of the state file to masterTasks if it is a master state, which lists
+// agents, otherwise to agentTasks.
+func parseMesosState(path string, masterTasks map[string]MesosTask, agentTasks map[string]MesosTask) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	state := mesosState{}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	tasks := agentTasks
+	if len(state.Slaves) != 0 {
+		tasks = masterTasks
+	}
+	hosts := make(map[string]string)
+	for _, agent := range state.Slaves {
+		hosts[agent.ID] = agent.Hostname
+	}
+	if state.ID != "" && state.Hostname != "" {
+		hosts[state.ID] = state.Hostname
+	}
+	add := func(frameworkID string, list []mesosTask) {
+		for _, t := range list {
+			m := t.convert(hosts)
+			if m.FrameworkID == "" {
+				m.FrameworkID = frameworkID
+			}
+			if m.AgentID == "" && len(state.Slaves) == 0 {
+				m.AgentID = state.ID
+				m.AgentHost = state.Hostname
+			}
+			tasks[m.ID] = mergeMesosTasks(tasks[m.ID], m)
+		}
+	}
+	for _, f := range append(state.Frameworks, state.CompletedFrameworks...) {
+		add(f.ID, f.Tasks)
+		add(f.ID, f.CompletedTasks)
+		add(f.ID, f.UnreachableTasks)
+		for _, e := range append(f.Executors, f.CompletedExecutors...) {
+			add(f.ID, e.Tasks)
+			add(f.ID, e.QueuedTasks)
+			add(f.ID, e.CompletedTasks)
+		}
+	}
+	return nil
+}
+
+func (t mesosTask) convert(hosts map[string]string) MesosTask {
+	m := MesosTask{
+		ID:          t.ID,
+		AgentID:     t.SlaveID,
+		AgentHost:   hosts[t.SlaveID],
+		FrameworkID: t.FrameworkID,
+		Resources:   make(map[string]float64),
+		Container:   t.Container.Type,
+	}
+	for name, raw := range t.Resources {
+		var v float64
+		if json.Unmarshal(raw, &v) == nil {
+			m.Resources[name] = v
+		}
+	}
+	latest := -1.0
+	for _, s := range t.Statuses {
+		if s.Timestamp >= latest {
+			latest = s.Timestamp
+			m.Reason = s.Reason
+			m.Message = s.Message
+		}
+	}
+	return m
+}
+
+// mergeMesosTasks fills the fields of a task found in one state file with the fields found in another one,
+// e.g., the agent hostname from the master state.
+func mergeMesosTasks(a MesosTask, b MesosTask) MesosTask {
+	if a.ID == "" {
+		return b
+	}
+	for _, f := range []struct{ dst, src *string }{
+		{&a.AgentID, &b.AgentID},
+		{&a.AgentHost, &b.AgentHost},
+		{&a.FrameworkID, &b.FrameworkID},
+		{&a.Container, &b.Container},
+		{&a.Reason, &b.Reason},
+		{&a.Message, &b.Message},
+	} {
+		if *f.dst == "" {
+			*f.dst = *f.src
+		}
+	}
+	if len(a.Resources) == 0 {
+		a.Resources = b.Resources
+	}
+	return a
+}
+
+// mesosStates are the Mesos tasks of the bundles by bundle path. The state files are big, so they are
+// read only by the commands which need the Mesos data, and only once.
+var mesosStates = struct {
+	sync.Mutex
+	byBundle map[string]map[string]MesosTask
+}{byBundle: make(map[string]map[string]MesosTask)}
+
+func bundleMesosTasks(bundlePath string) map[string]MesosTask {
+	mesosStates.Lock()
+	defer mesosStates.Unlock()
+	mesosTasks, ok := mesosStates.byBundle[bundlePath]
+	if ok {
+		return mesosTasks
+	}
+	mesosTasks, err := FindMesosTasks(bundlePath)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot find Mesos state: %v\n", err)
+	}
+	mesosStates.byBundle[bundlePath] = mesosTasks
+	return mesosTasks
+}
+
+// MesosState returns the Mesos state of the task or nil if the task is not found in the Mesos state files
+// of the bundle. The Mesos task ID is <task name>__<task ID>, the same as the end of the task directory name.
+func (t Task) MesosState() *MesosTask {
+	if t.Mesos != nil || t.bundlePath == "" {
+		return t.Mesos
+	}
+	mesosTasks := bundleMesosTasks(t.bundlePath)
+	m, ok := mesosTasks[t.Name+"__"+t.ID]
+	if !ok {
+		m, ok = mesosTasks[t.ID]
+	}
+	if !ok {
+		return nil
+	}
+	return &m
+}
+
+// mesos returns the Mesos state of the task or an empty MesosTask if it is unknown.
+func (t Task) mesos() MesosTask {
+	m := t.MesosState()
+	if m == nil {
+		return MesosTask{}
+	}
+	return *m
+}
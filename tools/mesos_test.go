@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindMesosTasks(t *testing.T) {
+	master := `{"slaves": [{"id": "S1", "hostname": "10.0.1.11"}], "frameworks": [{"id": "F1", "completed_tasks": [{
+		"id": "kafka-2-broker__06e119a6", "slave_id": "S1", "resources": {"cpus": 0.5, "ports": "[9092-9092]"},
+		"statuses": [{"timestamp": 2, "reason": "REASON_EXECUTOR_TERMINATED"}, {"timestamp": 1, "reason": "OLD"}]}]}]}`
+	agent := `{"id": "S1", "hostname": "agent-1", "frameworks": [{"id": "F1", "executors": [{"completed_tasks": [{
+		"id": "kafka-2-broker__06e119a6", "container": {"type": "DOCKER"}}]}]}]}`
+	// The master state wins regardless of the order in which the state files are found.
+	for _, names := range [][2]string{
+		{"master_state.json", "agent_state.json"},
+		{"a_master_state.json", "b_agent_state.json"},
+	} {
+		t.Run(names[0], func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "sbun-mesos")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = os.RemoveAll(dir) }()
+			for i, content := range []string{master, agent} {
+				if err := ioutil.WriteFile(filepath.Join(dir, names[i]), []byte(content), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tasks := []Task{
+				{Name: "kafka-2-broker", ID: "06e119a6", bundlePath: dir},
+				{Name: "kafka-0-broker", ID: "16e119a6", bundlePath: dir},
+			}
+			if m := tasks[1].MesosState(); m != nil {
+				t.Errorf("task which is not in the state has Mesos = %+v", *m)
+			}
+			got := tasks[0].mesos()
+			if got.AgentID != "S1" || got.FrameworkID != "F1" || got.Container != "DOCKER" ||
+				got.Reason != "REASON_EXECUTOR_TERMINATED" || got.FormatResources() != "cpus:0.5" {
+				t.Errorf("Mesos = %+v", got)
+			}
+			if got.AgentHost != "10.0.1.11" {
+				t.Errorf("AgentHost = %q, want the hostname from the master state", got.AgentHost)
+			}
+		})
+	}
+}
+
+func TestFindMesosTasks_generatedDirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-mesos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	state := `{"id": "S1", "hostname": "agent-1", "frameworks": [{"id": "F1", "tasks": [{"id": "kafka-0-broker__a"}]}]}`
+	writeTestFiles(t, dir, map[string][]byte{
+		filepath.Join("failed_tasks", ViewMarkerFileName):        nil,
+		filepath.Join("failed_tasks", "agent_state.json"):        []byte(state),
+		filepath.Join("extracted", ExtractManifestFileName):      nil,
+		filepath.Join("extracted", "nested", "agent_state.json"): []byte(state),
+	})
+	mesosTasks, err := FindMesosTasks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesosTasks) != 0 {
+		t.Errorf("FindMesosTasks() = %+v, want the state files of views and extracted bundles skipped", mesosTasks)
+	}
+}
+
+func TestFindTasks_lazyMesosState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-mesos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	state := `{"id": "S1", "hostname": "agent-1", "frameworks": [{"id": "F1", "tasks": [{"id": "kafka-0-broker__a"}]}]}`
+	writeTestFiles(t, dir, map[string][]byte{
+		filepath.Join(DirNameTasks, "starting_20200416T110149__kafka-0-broker__kafka-0-broker__a", "stdout"): []byte("line\n"),
+		"agent_state.json": []byte(state),
+	})
+	tasks, err := FindTasks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mesosStates.Lock()
+	_, read := mesosStates.byBundle[dir]
+	mesosStates.Unlock()
+	if read {
+		t.Errorf("FindTasks() read the Mesos state, want it read on first use")
+	}
+	if got := tasks[0].mesos().AgentHost; got != "agent-1" {
+		t.Errorf("AgentHost = %q, want %q", got, "agent-1")
+	}
+}
@@ -0,0 +1,290 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ServiceInfo is the service metadata found in the bundle.
+type ServiceInfo struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	Version string `json:"version"`
+	// File is the file the metadata was read from, relative to the bundle.
+	File string `json:"file"`
+}
+
+// Keys of the service metadata in the order of preference. Keys are compared with the flattened JSON keys
+// case-insensitively. Generic keys, like "name", match only at the top level, other keys also match
+// Marathon labels and environment variables, e.g., "labels.DCOS_PACKAGE_VERSION".
+var (
+	serviceNameKeys    = []string{"name", "service.name", "DCOS_SERVICE_NAME", "id"}
+	servicePackageKeys = []string{"packageName", "package_name", "DCOS_PACKAGE_NAME"}
+	serviceVersionKeys = []string{"packageVersion", "package_version", "DCOS_PACKAGE_VERSION", "version"}
+)
+
+// ReadServiceInfo reads the service name, package and version from the service configuration files of
+// the bundle, see check-bundle. Fields which are not found are empty.
+func ReadServiceInfo(bundlePath string) (ServiceInfo, error) {
+	info := ServiceInfo{}
+	infos, err := ioutil.ReadDir(bundlePath)
+	if err != nil {
+		return info, err
+	}
+	names := make([]string, 0)
+	for _, i := range infos {
+		if !i.IsDir() && isServiceConfigFile(i.Name()) {
+			names = append(names, i.Name())
+		}
+	}
+	// service.json goes first.
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[i] == "service.json" && names[j] != "service.json"
+	})
+	for _, name := range names {
+		data, err := ioutil.ReadFile(filepath.Join(bundlePath, name))
+		if err != nil {
+			return info, err
+		}
+		var v interface{}
+		if json.Unmarshal(data, &v) != nil {
+			continue
+		}
+		flat := make(map[string]string)
+		flattenJSON("", v, flat)
+		found := false
+		for _, f := range []struct {
+			dst  *string
+			keys []string
+		}{
+			{&info.Name, serviceNameKeys},
+			{&info.Package, servicePackageKeys},
+			{&info.Version, serviceVersionKeys},
+		} {
+			if *f.dst == "" {
+				*f.dst = findServiceKey(flat, f.keys)
+				found = found || *f.dst != ""
+			}
+		}
+		if found && info.File == "" {
+			info.File = name
+		}
+	}
+	info.Name = strings.TrimPrefix(info.Name, "/")
+	return info, nil
+}
+
+func findServiceKey(flat map[string]string, keys []string) string {
+	flatKeys := make([]string, 0, len(flat))
+	for flatKey := range flat {
+		flatKeys = append(flatKeys, flatKey)
+	}
+	sort.Strings(flatKeys)
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		generic := key == "name" || key == "id" || key == "version"
+		for _, flatKey := range flatKeys {
+			lower := strings.ToLower(flatKey)
+			match := lower == key
+			if !generic {
+				match = match || lower == "labels."+key || lower == "env."+key ||
+					strings.HasSuffix(lower, ".labels."+key) || strings.HasSuffix(lower, ".env."+key)
+			}
+			var s string
+			if match && json.Unmarshal([]byte(flat[flatKey]), &s) == nil && s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// PodTypeCount is the number of tasks of a pod type by state.
+type PodTypeCount struct {
+	PodType string         `json:"podType"`
+	Tasks   int            `json:"tasks"`
+	States  map[string]int `json:"states"`
+}
+
+// RestartCount is the number of restarts of a task name.
+type RestartCount struct {
+	TaskName string `json:"taskName"`
+	Restarts int    `json:"restarts"`
+}
+
+// Overview is the first look at a bundle.
+type Overview struct {
+	Bundle  string      `json:"bundle"`
+	Service ServiceInfo `json:"service"`
+	// From and To are the earliest and the latest task timestamps.
+	From             time.Time      `json:"from"`
+	To               time.Time      `json:"to"`
+	Tasks            int            `json:"tasks"`
+	States           map[string]int `json:"states"`
+	PodTypes         []PodTypeCount `json:"podTypes"`
+	Restarting       []RestartCount `json:"restarting"`
+	TasksWithoutLogs []string       `json:"tasksWithoutLogs"`
+	ErrorSignatures  []LogTemplate  `json:"errorSignatures"`
+	LargestLogs      []FileUsage    `json:"largestLogs"`
+}
+
+// BuildOverview collects the overview of the bundle. Only top error signatures and largest logs are kept,
+// if top is 0, all of them are kept.
+func BuildOverview(bundlePath string, tasks []Task, top int) (Overview, error) {
+	o := Overview{
+		Bundle:           bundlePath,
+		Tasks:            len(tasks),
+		States:           make(map[string]int),
+		PodTypes:         make([]PodTypeCount, 0),
+		Restarting:       make([]RestartCount, 0),
+		TasksWithoutLogs: make([]string, 0),
+		LargestLogs:      make([]FileUsage, 0),
+	}
+	var err error
+	if o.Service, err = ReadServiceInfo(bundlePath); err != nil {
+		return o, fmt.Errorf("cannot read service metadata: %v", err)
+	}
+	podTypes := make(map[string]*PodTypeCount)
+	for _, t := range tasks {
+		for _, ts := range []time.Time{t.Staring, t.Running, t.Killed, t.Failed} {
+			if ts.IsZero() {
+				continue
+			}
+			if o.From.IsZero() || ts.Before(o.From) {
+				o.From = ts
+			}
+			if ts.After(o.To) {
+				o.To = ts
+			}
+		}
+		o.States[t.State()]++
+		p, ok := podTypes[t.PodType()]
+		if !ok {
+			p = &PodTypeCount{PodType: t.PodType(), States: make(map[string]int)}
+			podTypes[t.PodType()] = p
+		}
+		p.Tasks++
+		p.States[t.State()]++
+		if !t.HasLogs {
+			o.TasksWithoutLogs = append(o.TasksWithoutLogs, t.DirName)
+		}
+	}
+	for _, p := range podTypes {
+		o.PodTypes = append(o.PodTypes, *p)
+	}
+	sort.Slice(o.PodTypes, func(i, j int) bool {
+		return o.PodTypes[i].PodType < o.PodTypes[j].PodType
+	})
+	for name, n := range CountRestarts(tasks) {
+		if n > 0 {
+			o.Restarting = append(o.Restarting, RestartCount{name, n})
+		}
+	}
+	sort.Slice(o.Restarting, func(i, j int) bool {
+		if o.Restarting[i].Restarts != o.Restarting[j].Restarts {
+			return o.Restarting[i].Restarts > o.Restarting[j].Restarts
+		}
+		return o.Restarting[i].TaskName < o.Restarting[j].TaskName
+	})
+	if o.ErrorSignatures, err = MineErrorSignatures(tasks); err != nil {
+		return o, err
+	}
+	if top > 0 && len(o.ErrorSignatures) > top {
+		o.ErrorSignatures = o.ErrorSignatures[:top]
+	}
+	du, err := MeasureDiskUsage(tasks, false)
+	if err != nil {
+		return o, err
+	}
+	for _, f := range du.Files {
+		if top > 0 && len(o.LargestLogs) == top {
+			break
+		}
+		if f.Stream != StreamOther {
+			o.LargestLogs = append(o.LargestLogs, f)
+		}
+	}
+	return o, nil
+}
+
+// WriteOverview prints the overview in the text or json format.
+func WriteOverview(w io.Writer, o Overview, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(o)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	orUnknown := func(s string) string {
+		if s == "" {
+			return "unknown"
+		}
+		return s
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Bundle:\t%v\n", o.Bundle)
+	_, _ = fmt.Fprintf(tw, "Service:\t%v\n", orUnknown(o.Service.Name))
+	_, _ = fmt.Fprintf(tw, "Package:\t%v %v\n", orUnknown(o.Service.Package), o.Service.Version)
+	_, _ = fmt.Fprintf(tw, "Time range:\t%v - %v\n", printTime(o.From), printTime(o.To))
+	states := make([]string, 0)
+	for _, state := range []string{StateStarting, StateRunning, StateKilled, StateFailed} {
+		states = append(states, fmt.Sprintf("%v %v", o.States[state], state))
+	}
+	_, _ = fmt.Fprintf(tw, "Tasks:\t%v (%v)\n", o.Tasks, strings.Join(states, ", "))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintln(w, "\nPod types:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  POD TYPE\tTASKS\tSTARTING\tRUNNING\tKILLED\tFAILED")
+	for _, p := range o.PodTypes {
+		_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\t%v\t%v\n", p.PodType, p.Tasks, p.States[StateStarting],
+			p.States[StateRunning], p.States[StateKilled], p.States[StateFailed])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "\nRestarting instances: %v\n", len(o.Restarting))
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, r := range o.Restarting {
+		_, _ = fmt.Fprintf(tw, "  %v\t%v restarts\n", r.TaskName, r.Restarts)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "\nTasks without logs: %v\n", len(o.TasksWithoutLogs))
+	for _, dirName := range o.TasksWithoutLogs {
+		_, _ = fmt.Fprintf(w, "  %v\n", dirName)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nTop error signatures:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  COUNT\tTASKS\tSIGNATURE")
+	for _, t := range o.ErrorSignatures {
+		_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\n", t.Count, len(t.Sources), t.Template)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, _ = fmt.Fprintf(w, "\nLargest logs:\n")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  UNCOMPRESSED\tSTREAM\tPATH")
+	for _, f := range o.LargestLogs {
+		_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\n", humanSize(f.Uncompressed), f.Stream, f.Path)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,35 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func Test_findServiceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		keys []string
+		want string
+	}{
+		{"top-level name", `{"name": "kafka", "pods": [{"name": "broker"}]}`, serviceNameKeys, "kafka"},
+		{"nested name is ignored", `{"pods": [{"name": "broker"}]}`, serviceNameKeys, ""},
+		{"service name", `{"service": {"name": "kafka-dev"}}`, serviceNameKeys, "kafka-dev"},
+		{"Marathon label", `{"app": {"labels": {"DCOS_PACKAGE_VERSION": "2.9.0"}}}`, serviceVersionKeys, "2.9.0"},
+		{"environment variable", `{"env": {"PACKAGE_VERSION": "2.9.0"}}`, serviceVersionKeys, "2.9.0"},
+		{"preferred key first", `{"version": "1", "packageVersion": "2"}`, serviceVersionKeys, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.json), &v); err != nil {
+				t.Fatal(err)
+			}
+			flat := make(map[string]string)
+			flattenJSON("", v, flat)
+			if got := findServiceKey(flat, tt.keys); got != tt.want {
+				t.Errorf("findServiceKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Plan is a deployment, recovery or another plan of the service scheduler.
+type Plan struct {
+	Name     string   `json:"name"`
+	File     string   `json:"file"`
+	Status   string   `json:"status"`
+	Strategy string   `json:"strategy"`
+	Errors   []string `json:"errors"`
+	Phases   []Phase  `json:"phases"`
+}
+
+type Phase struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Strategy string `json:"strategy"`
+	Steps    []Step `json:"steps"`
+}
+
+type Step struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// NeedsAttention reports whether the step status means that the plan is stuck or failed.
+func (s Step) NeedsAttention() bool {
+	return statusNeedsAttention(s.Status)
+}
+
+// NeedsAttention reports whether the phase status means that the plan is stuck or failed.
+func (p Phase) NeedsAttention() bool {
+	return statusNeedsAttention(p.Status)
+}
+
+// NeedsAttention reports whether the plan has errors or its status means that it is stuck or failed.
+func (p Plan) NeedsAttention() bool {
+	return len(p.Errors) != 0 || statusNeedsAttention(p.Status)
+}
+
+func statusNeedsAttention(status string) bool {
+	switch strings.ToUpper(status) {
+	case "PENDING", "ERROR", "WAITING":
+		return true
+	}
+	return false
+}
+
+var planSeparatorsRegexp = regexp.MustCompile(`[-_.]+`)
+
+// dirNamePlans is the top-level directory with plan files in some bundles.
+const dirNamePlans = "plans"
+
+// FindPlans parses JSON files with "plan" in their path in the bundle root and in the scheduler and plans
+// directories. Views and extracted sub-bundles inside these directories are skipped. A file can have a single
+// plan or an object with plans by name.
+func FindPlans(bundlePath string) ([]Plan, error) {
+	plans := make([]Plan, 0)
+	err := filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot walk into path %v: %v\n", path, err)
+			return nil
+		}
+		rel, err := filepath.Rel(bundlePath, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel != "." && (!isPlanLocation(rel) || isGeneratedDir(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isPlanFile(rel) {
+			return nil
+		}
+		filePlans, err := parsePlanFile(path, rel)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot parse plan file %v: %v\n", rel, err)
+			return nil
+		}
+		plans = append(plans, filePlans...)
+		return nil
+	})
+	sort.SliceStable(plans, func(i, j int) bool {
+		return plans[i].Name < plans[j].Name
+	})
+	return plans, err
+}
+
+// isPlanLocation reports whether the path relative to the bundle is in a directory with plan files.
+func isPlanLocation(rel string) bool {
+	top := strings.SplitN(filepath.ToSlash(rel), "/", 2)[0]
+	return top == DirNameScheduler || top == dirNamePlans
+}
+
+// isGeneratedDir reports whether the directory is a view or an extracted sub-bundle created by SBun.
+func isGeneratedDir(path string) bool {
+	for _, marker := range []string{ViewMarkerFileName, ExtractManifestFileName} {
+		if _, err := os.Stat(filepath.Join(path, marker)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isPlanFile(rel string) bool {
+	if filepath.Ext(rel) != ".json" || !strings.Contains(strings.ToLower(rel), "plan") {
+		return false
+	}
+	return filepath.Dir(rel) == "." || isPlanLocation(rel)
+}
+
+func parsePlanFile(path string, rel string) ([]Plan, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		// E.g., the list of plan names.
+		return nil, nil
+	}
+	if _, ok := obj["phases"]; ok {
+		plan := Plan{}
+		if err := json.Unmarshal(data, &plan); err != nil {
+			return nil, err
+		}
+		plan.Name = planNameFromPath(rel)
+		plan.File = rel
+		return []Plan{plan}, nil
+	}
+	plans := make([]Plan, 0)
+	for name, value := range obj {
+		if m, ok := value.(map[string]interface{}); !ok || m["phases"] == nil {
+			continue
+		}
+		valueData, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		plan := Plan{}
+		if err := json.Unmarshal(valueData, &plan); err != nil {
+			return nil, err
+		}
+		plan.Name = name
+		plan.File = rel
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// planNameFromPath returns "deploy" for "plans/deploy.json", "deploy_plan.json" and "plan-deploy.json".
+func planNameFromPath(rel string) string {
+	name := strings.TrimSuffix(filepath.Base(rel), ".json")
+	tokens := make([]string, 0)
+	for _, token := range planSeparatorsRegexp.Split(name, -1) {
+		if lower := strings.ToLower(token); lower != "plan" && lower != "plans" && token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		return filepath.Base(filepath.Dir(rel))
+	}
+	return strings.Join(tokens, "-")
+}
+
+// WritePlans prints the plan, phase and step tree in the text or json format. Statuses which need
+// attention are marked with "<<".
+func WritePlans(w io.Writer, plans []Plan, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(plans)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, plan := range plans {
+		if i != 0 {
+			_, _ = fmt.Fprintln(tw, "\t\t\t")
+		}
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", plan.Name, plan.Strategy, plan.Status, attentionMark(plan.NeedsAttention()))
+		for _, e := range plan.Errors {
+			_, _ = fmt.Fprintf(tw, "  error: %v\t\t\t\n", e)
+		}
+		for _, phase := range plan.Phases {
+			_, _ = fmt.Fprintf(tw, "  %v\t%v\t%v\t%v\n", phase.Name, phase.Strategy, phase.Status,
+				attentionMark(phase.NeedsAttention()))
+			for _, step := range phase.Steps {
+				message := ""
+				if step.Message != "" && step.NeedsAttention() {
+					message = " " + step.Message
+				}
+				_, _ = fmt.Fprintf(tw, "    %v\t\t%v\t%v%v\n", step.Name, step.Status,
+					attentionMark(step.NeedsAttention()), message)
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+func attentionMark(needsAttention bool) string {
+	if needsAttention {
+		return "<<"
+	}
+	return ""
+}
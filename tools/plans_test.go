@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_planNameFromPath(t *testing.T) {
+	tests := []struct {
+		rel  string
+		want string
+	}{
+		{"plans/deploy.json", "deploy"},
+		{"scheduler/deploy_plan.json", "deploy"},
+		{"plan-recovery.json", "recovery"},
+		{"scheduler/Plans.Update.json", "Update"},
+		{"scheduler/plan-sidecar_backup.json", "sidecar-backup"},
+		{"scheduler/deploy/plan.json", "deploy"},
+	}
+	for _, tt := range tests {
+		if got := planNameFromPath(tt.rel); got != tt.want {
+			t.Errorf("planNameFromPath(%v) = %v, want %v", tt.rel, got, tt.want)
+		}
+	}
+}
+
+const testPlan = `{"phases": [{"id": "1", "name": "broker", "status": "IN_PROGRESS", "strategy": "serial",
+  "steps": [{"id": "2", "name": "kafka-0:[broker]", "status": "PENDING", "message": "waiting for offers"}]}],
+  "errors": [], "status": "IN_PROGRESS", "strategy": "serial"}`
+
+func Test_parsePlanFile(t *testing.T) {
+	deploy := Plan{Name: "deploy", File: "scheduler/plan-deploy.json", Status: "IN_PROGRESS", Strategy: "serial",
+		Errors: []string{}, Phases: []Phase{{ID: "1", Name: "broker", Status: "IN_PROGRESS", Strategy: "serial",
+			Steps: []Step{{ID: "2", Name: "kafka-0:[broker]", Status: "PENDING", Message: "waiting for offers"}}}}}
+	recovery := Plan{Name: "recovery", File: "scheduler/plans.json", Status: "COMPLETE",
+		Phases: []Phase{}}
+	tests := []struct {
+		name    string
+		rel     string
+		content string
+		want    []Plan
+		wantErr bool
+	}{
+		{"parses a single plan", "scheduler/plan-deploy.json", testPlan, []Plan{deploy}, false},
+		{
+			"parses plans by name",
+			"scheduler/plans.json",
+			`{"recovery": {"phases": [], "status": "COMPLETE"}, "version": "1"}`,
+			[]Plan{recovery},
+			false,
+		},
+		{"ignores the list of plan names", "scheduler/plans.json", `["deploy", "recovery"]`, nil, false},
+		{"fails on invalid JSON", "scheduler/plans.json", `{"phases": [`, nil, true},
+	}
+	dir, err := ioutil.TempDir("", "sbun-plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "plan.json")
+			if err := ioutil.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := parsePlanFile(path, tt.rel)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePlanFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlanFile() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPlans(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	writeTestFiles(t, dir, map[string][]byte{
+		"plan-deploy.json":                               []byte(testPlan),
+		"scheduler/plans/recovery.json":                  []byte(testPlan),
+		"plans/update.json":                              []byte(testPlan),
+		"tasks/kafka-0-broker/plan-copy.json":            []byte(testPlan),
+		"sub-bundle/scheduler/plan-deploy.json":          []byte(testPlan),
+		"scheduler/view/" + ViewMarkerFileName:           []byte("{}"),
+		"scheduler/view/task/plan-deploy.json":           []byte(testPlan),
+		"scheduler/extracted/" + ExtractManifestFileName: []byte("{}"),
+		"scheduler/extracted/scheduler/plan-deploy.json": []byte(testPlan),
+	})
+	plans, err := FindPlans(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make([]string, 0, len(plans))
+	for _, p := range plans {
+		files = append(files, p.File)
+	}
+	want := []string{"plan-deploy.json", "scheduler/plans/recovery.json", "plans/update.json"}
+	for i := range want {
+		want[i] = filepath.FromSlash(want[i])
+	}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("FindPlans() found %v, want %v", files, want)
+	}
+}
+
+func TestFindPlans_extractedBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	bundle, sub := filepath.Join(dir, "bundle"), filepath.Join(dir, "sub")
+	writeTestFiles(t, bundle, map[string][]byte{
+		"scheduler/deploy_plan.json": []byte(testPlan),
+		"tasks/starting_20200416T110000__kafka-0-broker__kafka-0-broker__a/stdout": []byte("log\n"),
+	})
+	if _, err := Extract(bundle, sub, ExtractOptions{Include: []string{DirNameScheduler}}); err != nil {
+		t.Fatal(err)
+	}
+	// The sub-bundle has the extract manifest in its root.
+	plans, err := FindPlans(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plans) != 1 || plans[0].File != filepath.Join(DirNameScheduler, "deploy_plan.json") {
+		t.Errorf("FindPlans() found %+v in the extracted bundle, want the deploy plan", plans)
+	}
+}
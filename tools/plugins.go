@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// PluginPrefix is the prefix of the names of plugin executables, e.g., sbun-kafka-lag.
+const PluginPrefix = "sbun-"
+
+// PluginBundleEnv is the environment variable with the absolute bundle path given to plugins.
+const PluginBundleEnv = "SBUN_BUNDLE"
+
+// Plugin is an external executable which extends SBun with a subcommand.
+type Plugin struct {
+	// Name is the subcommand name, i.e., the executable name without PluginPrefix.
+	Name string
+	Path string
+}
+
+// FindPlugins returns the plugins found on PATH ordered by name. If there are several executables with
+// the same name, the first one on PATH is used, like a shell does.
+func FindPlugins() []Plugin {
+	byName := make(map[string]Plugin)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			name := info.Name()
+			if runtime.GOOS == "windows" {
+				if !strings.EqualFold(filepath.Ext(name), ".exe") {
+					continue
+				}
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if !strings.HasPrefix(name, PluginPrefix) || len(name) == len(PluginPrefix) {
+				continue
+			}
+			path := filepath.Join(dir, info.Name())
+			// Links are followed to check that the target is an executable file.
+			if info, err = os.Stat(path); err != nil || info.IsDir() {
+				continue
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+				continue
+			}
+			name = strings.TrimPrefix(name, PluginPrefix)
+			if _, ok := byName[name]; !ok {
+				byName[name] = Plugin{Name: name, Path: path}
+			}
+		}
+	}
+	plugins := make([]Plugin, 0, len(byName))
+	for _, p := range byName {
+		plugins = append(plugins, p)
+	}
+	sort.Slice(plugins, func(i, j int) bool {
+		return plugins[i].Name < plugins[j].Name
+	})
+	return plugins
+}
+
+// PluginInput is the JSON document plugins read from the standard input.
+type PluginInput struct {
+	// Bundle is the absolute path to the bundle.
+	Bundle string       `json:"bundle"`
+	Tasks  []PluginTask `json:"tasks"`
+}
+
+// PluginTask is a parsed task with the absolute paths to its log files.
+type PluginTask struct {
+	ReportTask
+	// LogFiles are the log files by stream name, the oldest rotation first, see TaskLogFiles.
+	LogFiles map[string][]string `json:"logFiles"`
+	// Mesos is null if the task is not found in the Mesos state files of the bundle.
+	Mesos *MesosTask `json:"mesos"`
+}
+
+// NewPluginInput collects the plugin input from the tasks of the bundle.
+func NewPluginInput(bundlePath string, tasks []Task) (PluginInput, error) {
+	abs, err := filepath.Abs(bundlePath)
+	if err != nil {
+		return PluginInput{}, err
+	}
+	input := PluginInput{Bundle: abs, Tasks: make([]PluginTask, 0, len(tasks))}
+	for _, t := range tasks {
+		pt := PluginTask{ReportTask: newReportTask(t), LogFiles: make(map[string][]string), Mesos: t.MesosState()}
+		for _, stream := range LogStreamNames() {
+			paths, err := TaskLogFiles(t, stream)
+			if err != nil {
+				return input, err
+			}
+			for _, path := range paths {
+				if path, err = filepath.Abs(path); err != nil {
+					return input, err
+				}
+				pt.LogFiles[stream] = append(pt.LogFiles[stream], path)
+			}
+		}
+		input.Tasks = append(input.Tasks, pt)
+	}
+	return input, nil
+}
+
+// RunPlugin runs the plugin with the arguments and writes the input to its standard input as JSON.
+// The bundle path is also given in the PluginBundleEnv environment variable. If the plugin exits with
+// a non-zero code, the error is *exec.ExitError.
+func RunPlugin(p Plugin, input PluginInput, args []string, stdout io.Writer, stderr io.Writer) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(p.Path, args...)
+	cmd.Env = append(os.Environ(), PluginBundleEnv+"="+input.Bundle)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
@@ -0,0 +1,103 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestFindPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("plugins are found by the .exe extension on Windows")
+	}
+	dir, err := ioutil.TempDir("", "sbun-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	first, second := filepath.Join(dir, "first"), filepath.Join(dir, "second")
+	files := map[string]os.FileMode{
+		filepath.Join(first, "sbun-lag"):      0755,
+		filepath.Join(first, "sbun-notes"):    0644,
+		filepath.Join(first, "sbun-"):         0755,
+		filepath.Join(first, "kubectl-lag"):   0755,
+		filepath.Join(second, "sbun-lag"):     0755,
+		filepath.Join(second, "sbun-offsets"): 0755,
+	}
+	for path, mode := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte("#!/bin/sh\n"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(path string) { _ = os.Setenv("PATH", path) }(os.Getenv("PATH"))
+	if err := os.Setenv("PATH", first+string(filepath.ListSeparator)+second); err != nil {
+		t.Fatal(err)
+	}
+	want := []Plugin{
+		{Name: "lag", Path: filepath.Join(first, "sbun-lag")},
+		{Name: "offsets", Path: filepath.Join(second, "sbun-offsets")},
+	}
+	if got := FindPlugins(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPlugins() = %v, want %v", got, want)
+	}
+}
+
+func TestRunPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test plugin is a shell script")
+	}
+	dir, err := ioutil.TempDir("", "sbun-plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task, err := parseTaskDirName("starting_20200416T110000-running_20200416T110100__kafka-0-broker__kafka-0-broker__a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.DirNameAbsolute = filepath.Join(dir, "bundle", DirNameTasks, task.DirName)
+	task.Mesos = &MesosTask{ID: "kafka-0-broker__a", AgentHost: "10.0.1.11"}
+	writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{"stdout": []byte("log\n"), "stdout.1": []byte("old\n")})
+	input, err := NewPluginInput(filepath.Join(dir, "bundle"), []Task{task})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The plugin saves its input and prints its arguments and the bundle path.
+	script := "#!/bin/sh\ncat > \"$SBUN_BUNDLE/../input.json\"\necho \"$@ $SBUN_BUNDLE\"\nexit 3\n"
+	path := filepath.Join(dir, "sbun-test")
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	var stdout bytes.Buffer
+	err = RunPlugin(Plugin{Name: "test", Path: path}, input, []string{"--top", "3"}, &stdout, ioutil.Discard)
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("RunPlugin() error = %v, want exit code 3", err)
+	}
+	if want := "--top 3 " + input.Bundle + "\n"; stdout.String() != want {
+		t.Errorf("RunPlugin() printed %q, want %q", stdout.String(), want)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "input.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := PluginInput{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, input) {
+		t.Errorf("plugin input = %+v, want %+v", got, input)
+	}
+	wantLogs := []string{filepath.Join(task.DirNameAbsolute, "stdout.1"), filepath.Join(task.DirNameAbsolute, "stdout")}
+	if !filepath.IsAbs(input.Bundle) || !reflect.DeepEqual(input.Tasks[0].LogFiles[StreamStdout], wantLogs) {
+		t.Errorf("NewPluginInput() = %+v, want the absolute bundle path and log files %v", input, wantLogs)
+	}
+}
@@ -0,0 +1,295 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector finds sensitive values in text. If the regexp has groups, only the first group is replaced,
+// so that, e.g., "password=" stays in the line and only the password itself is hidden.
+type Detector struct {
+	Name   string
+	Regexp *regexp.Regexp
+}
+
+// DefaultDetectors are the detectors used by the redact command.
+var DefaultDetectors = []Detector{
+	{"JWT", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	{"AWS-KEY", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"SECRET", regexp.MustCompile(
+		`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|secret[_-]?key|credentials?)` +
+			`["']?\s*[=:]\s*["']?([^\s"',;&}]+)`)},
+	{"EMAIL", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{"IP", regexp.MustCompile(`\b(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])(?:\.(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])){3}\b`)},
+}
+
+// ParseDetector parses a custom detector in the <name>=<regexp> format.
+func ParseDetector(s string) (Detector, error) {
+	tokens := strings.SplitN(s, "=", 2)
+	if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+		return Detector{}, fmt.Errorf("detector %q is not in the <name>=<regexp> format", s)
+	}
+	r, err := regexp.Compile(tokens[1])
+	if err != nil {
+		return Detector{}, fmt.Errorf("cannot compile detector %q: %v", tokens[0], err)
+	}
+	return Detector{strings.ToUpper(tokens[0]), r}, nil
+}
+
+// Redactor replaces sensitive values with placeholders. The same value always gets the same placeholder,
+// e.g., every occurrence of 10.0.0.1 becomes [IP-1], so the redacted logs can still be correlated.
+type Redactor struct {
+	detectors  []Detector
+	pseudonyms map[string]map[string]string
+	// Counts is the number of replaced occurrences per detector.
+	Counts map[string]int
+}
+
+// DetectorCount is the number of occurrences replaced by a detector.
+type DetectorCount struct {
+	Name  string
+	Count int
+}
+
+// SortedCounts returns Counts ordered by detector name.
+func (r *Redactor) SortedCounts() []DetectorCount {
+	counts := make([]DetectorCount, 0, len(r.Counts))
+	for name, count := range r.Counts {
+		counts = append(counts, DetectorCount{name, count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func NewRedactor(detectors []Detector) *Redactor {
+	return &Redactor{
+		detectors:  detectors,
+		pseudonyms: make(map[string]map[string]string),
+		Counts:     make(map[string]int),
+	}
+}
+
+// RedactLine returns the line with all the detected values replaced. Detectors are applied to the original
+// line in order and a value found by a detector is not matched again by the next ones, e.g., a JWT in
+// "token=eyJ..." stays [JWT-1] and doesn't become a secret.
+func (r *Redactor) RedactLine(line string) string {
+	type span struct {
+		start, end int
+		kind       string
+	}
+	spans := make([]span, 0)
+	for _, d := range r.detectors {
+	matches:
+		for _, m := range d.Regexp.FindAllStringSubmatchIndex(line, -1) {
+			s := span{m[0], m[1], d.Name}
+			if len(m) > 2 && m[2] >= 0 {
+				s.start, s.end = m[2], m[3]
+			}
+			if s.start == s.end {
+				continue
+			}
+			for _, other := range spans {
+				if s.start < other.end && other.start < s.end {
+					continue matches
+				}
+			}
+			spans = append(spans, s)
+		}
+	}
+	if len(spans) == 0 {
+		return line
+	}
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+	b := strings.Builder{}
+	prev := 0
+	for _, s := range spans {
+		b.WriteString(line[prev:s.start])
+		b.WriteString(r.pseudonym(s.kind, line[s.start:s.end]))
+		prev = s.end
+	}
+	b.WriteString(line[prev:])
+	return b.String()
+}
+
+func (r *Redactor) pseudonym(kind string, value string) string {
+	r.Counts[kind]++
+	values, ok := r.pseudonyms[kind]
+	if !ok {
+		values = make(map[string]string)
+		r.pseudonyms[kind] = values
+	}
+	p, ok := values[value]
+	if !ok {
+		p = fmt.Sprintf("[%v-%v]", kind, len(values)+1)
+		values[value] = p
+	}
+	return p
+}
+
+// WriteMapping writes placeholders and the original values. The mapping is sensitive, it must not
+// be shared along with the redacted files.
+func (r *Redactor) WriteMapping(w io.Writer) error {
+	lines := make([]string, 0)
+	for _, values := range r.pseudonyms {
+		for value, p := range values {
+			lines = append(lines, p+"\t"+value)
+		}
+	}
+	sort.Strings(lines)
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedactReport lists what RedactBundle did with the bundle files.
+type RedactReport struct {
+	Redacted []string
+	// Omitted are binary files, symbolic links and files which could not be read.
+	Omitted []string
+	Copied  []string
+}
+
+// RedactBundle writes redacted copies of all text files of the bundle, compressed or not, to outPath
+// keeping the directory layout and the file names. Binary files are omitted unless copyBinary is true.
+func RedactBundle(bundlePath string, outPath string, r *Redactor, copyBinary bool) (RedactReport, error) {
+	report := RedactReport{}
+	absOut, err := filepath.Abs(outPath)
+	if err != nil {
+		return report, err
+	}
+	if err := os.MkdirAll(outPath, 0777); err != nil {
+		return report, fmt.Errorf("cannot create output directory: %v", err)
+	}
+	err = filepath.Walk(bundlePath, func(path string, info os.FileInfo, err error) error {
+		rel, relErr := filepath.Rel(bundlePath, path)
+		if relErr != nil {
+			return relErr
+		}
+		if err != nil {
+			report.Omitted = append(report.Omitted, rel)
+			return nil
+		}
+		if info.IsDir() {
+			if abs, _ := filepath.Abs(path); abs == absOut {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(outPath, rel), 0777)
+		}
+		if !info.Mode().IsRegular() {
+			report.Omitted = append(report.Omitted, rel)
+			return nil
+		}
+		redacted, err := redactFile(path, filepath.Join(outPath, rel), r, copyBinary)
+		switch {
+		case err != nil:
+			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot redact %v: %v\n", rel, err)
+			report.Omitted = append(report.Omitted, rel)
+		case redacted:
+			report.Redacted = append(report.Redacted, rel)
+		case copyBinary:
+			report.Copied = append(report.Copied, rel)
+		default:
+			report.Omitted = append(report.Omitted, rel)
+		}
+		return nil
+	})
+	return report, err
+}
+
+// redactFile returns true if the file was redacted and false if the file is binary.
+func redactFile(path string, outPath string, r *Redactor, copyBinary bool) (bool, error) {
+	in, err := fileReader(path)
+	if err != nil {
+		return false, err
+	}
+	defer closeCloser(in)
+	br := bufio.NewReaderSize(in, 64*1024)
+	head, err := br.Peek(8 * 1024)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	if isBinary(head) {
+		if !copyBinary {
+			return false, nil
+		}
+		return false, copyFile(path, outPath)
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return false, err
+	}
+	defer closeCloser(f)
+	var out io.Writer = f
+	if filepath.Ext(path) == ".gz" {
+		gzw := gzip.NewWriter(f)
+		defer closeCloser(gzw)
+		out = gzw
+	}
+	bw := bufio.NewWriter(out)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			content := strings.TrimRight(line, "\r\n")
+			if _, err := bw.WriteString(r.RedactLine(content) + line[len(content):]); err != nil {
+				return false, err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, bw.Flush()
+}
+
+// isBinary treats a file as binary if its beginning has a NUL byte. Invalid UTF-8 is not enough,
+// because logs can be written in other encodings.
+func isBinary(head []byte) bool {
+	return bytes.IndexByte(head, 0) >= 0
+}
+
+// copyFile copies the file preserving its permissions and modification time.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer closeCloser(in)
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	// The permissions of a new file are limited by umask.
+	if err := os.Chmod(dst, info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
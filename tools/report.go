@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ReportOptions define how much BuildReport puts into the report.
+type ReportOptions struct {
+	// Findings is the number of error signatures to include, 0 means all.
+	Findings int
+	// ExcerptContext is the number of lines before and after the example line in a log excerpt.
+	ExcerptContext int
+}
+
+// DefaultReportOptions are the report options used by the report command by default.
+var DefaultReportOptions = ReportOptions{Findings: 10, ExcerptContext: 3}
+
+// Report is the data model of the HTML and Markdown reports.
+type Report struct {
+	GeneratedAt time.Time        `json:"generatedAt"`
+	Overview    Overview         `json:"overview"`
+	Tasks       []ReportTask     `json:"tasks"`
+	Restarts    []RestartHistory `json:"restarts"`
+	Findings    []Finding        `json:"findings"`
+}
+
+// ReportTask is a task with the fields shown in the reports.
+type ReportTask struct {
+	Name    string    `json:"name"`
+	ID      string    `json:"id"`
+	DirName string    `json:"dirName"`
+	Pod     string    `json:"pod"`
+	PodType string    `json:"podType"`
+	State   string    `json:"state"`
+	Agent   string    `json:"agent"`
+	Started time.Time `json:"started"`
+	// Ended is the latest timestamp of the task.
+	Ended   time.Time `json:"ended"`
+	HasLogs bool      `json:"hasLogs"`
+}
+
+// RestartHistory is the runs of a restarted task name ordered by the start time.
+type RestartHistory struct {
+	TaskName string       `json:"taskName"`
+	Runs     []ReportTask `json:"runs"`
+}
+
+// Finding is an error signature with log excerpts.
+type Finding struct {
+	LogTemplate
+	Excerpts []LogExcerpt `json:"excerpts"`
+}
+
+// LogExcerpt is a part of a task log around an example line.
+type LogExcerpt struct {
+	TaskDirName string `json:"taskDirName"`
+	Stream      string `json:"stream"`
+	// Path is the log file relative to the bundle.
+	Path string `json:"path"`
+	// Line is the number of the first line of the excerpt in the log, starting from 1.
+	Line  int      `json:"line"`
+	Lines []string `json:"lines"`
+	// Example is the index of the example line in Lines.
+	Example int `json:"example"`
+}
+
+// BuildReport collects the data for the reports from the tasks of the bundle.
+func BuildReport(bundlePath string, tasks []Task, opts ReportOptions) (Report, error) {
+	r := Report{GeneratedAt: time.Now().UTC(), Tasks: make([]ReportTask, 0, len(tasks)),
+		Restarts: make([]RestartHistory, 0), Findings: make([]Finding, 0)}
+	var err error
+	if r.Overview, err = BuildOverview(bundlePath, tasks, opts.Findings); err != nil {
+		return r, err
+	}
+	byName := make(map[string][]ReportTask)
+	byDirName := make(map[string]Task)
+	for _, t := range tasks {
+		rt := newReportTask(t)
+		r.Tasks = append(r.Tasks, rt)
+		byName[t.Name] = append(byName[t.Name], rt)
+		byDirName[t.DirName] = t
+	}
+	sort.SliceStable(r.Tasks, func(i, j int) bool {
+		return r.Tasks[i].Started.Before(r.Tasks[j].Started)
+	})
+	for name, runs := range byName {
+		if len(runs) < 2 {
+			continue
+		}
+		sort.Slice(runs, func(i, j int) bool {
+			return runs[i].Started.Before(runs[j].Started)
+		})
+		r.Restarts = append(r.Restarts, RestartHistory{name, runs})
+	}
+	sort.Slice(r.Restarts, func(i, j int) bool {
+		return r.Restarts[i].TaskName < r.Restarts[j].TaskName
+	})
+	for _, signature := range r.Overview.ErrorSignatures {
+		f := Finding{LogTemplate: signature, Excerpts: make([]LogExcerpt, 0)}
+		if task, ok := byDirName[signature.Source]; ok {
+			excerpt, err := findExcerpt(bundlePath, task, signature.Example, opts.ExcerptContext)
+			if err != nil {
+				return r, err
+			}
+			if excerpt != nil {
+				f.Excerpts = append(f.Excerpts, *excerpt)
+			}
+		}
+		r.Findings = append(r.Findings, f)
+	}
+	return r, nil
+}
+
+func newReportTask(t Task) ReportTask {
+	rt := ReportTask{
+		Name:    t.Name,
+		ID:      t.ID,
+		DirName: t.DirName,
+		Pod:     t.PodInstance(),
+		PodType: t.PodType(),
+		State:   t.State(),
+		Agent:   t.mesos().AgentHost,
+		Started: t.Started(),
+		HasLogs: t.HasLogs,
+	}
+	for _, ts := range []time.Time{t.Staring, t.Running, t.Killed, t.Failed} {
+		if ts.After(rt.Ended) {
+			rt.Ended = ts
+		}
+	}
+	return rt
+}
+
+// findExcerpt finds the example line in the log streams of the task and returns it with the context lines.
+// It returns nil if the line is not found.
+func findExcerpt(bundlePath string, task Task, example string, context int) (*LogExcerpt, error) {
+	errFound := errors.New("found")
+	for _, stream := range LogStreamNames() {
+		paths, err := TaskLogFiles(task, stream)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			r, err := fileReader(path)
+			if err != nil {
+				return nil, err
+			}
+			var before []string
+			var excerpt *LogExcerpt
+			n := 0
+			err = forEachLineErr(r, func(line string) error {
+				n++
+				if excerpt != nil {
+					excerpt.Lines = append(excerpt.Lines, line)
+					if len(excerpt.Lines) > excerpt.Example+context {
+						return errFound
+					}
+					return nil
+				}
+				if line == example {
+					rel, _ := filepath.Rel(bundlePath, path)
+					excerpt = &LogExcerpt{TaskDirName: task.DirName, Stream: stream, Path: rel,
+						Line: n - len(before), Lines: append(before, line), Example: len(before)}
+					return nil
+				}
+				before = append(before, line)
+				if len(before) > context {
+					before = before[1:]
+				}
+				return nil
+			})
+			closeCloser(r)
+			if err != nil && err != errFound {
+				return nil, err
+			}
+			if excerpt != nil {
+				return excerpt, nil
+			}
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// WriteReportHTML writes the report as a single HTML page which doesn't need anything else to be viewed:
+// the styles and the script sorting the task table are embedded. Links to the log files are relative to
+// the bundle directory, so they work if the report is saved in the bundle directory.
+func WriteReportHTML(w io.Writer, r Report) error {
+	from, to := r.Overview.From, r.Overview.To
+	span := to.Sub(from)
+	percent := func(t time.Time) float64 {
+		if span <= 0 || t.IsZero() {
+			return 0
+		}
+		return float64(t.Sub(from)) / float64(span) * 100
+	}
+	funcs := template.FuncMap{
+		"time": printTime,
+		"sortTime": func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.Format(time.RFC3339)
+		},
+		"shortID": shortID,
+		"size":    humanSize,
+		"left":    func(t ReportTask) string { return fmt.Sprintf("%.2f%%", percent(t.Started)) },
+		"width": func(t ReportTask) string {
+			end := t.Ended
+			if t.State == StateRunning {
+				// The task was still running when the bundle was collected.
+				end = to
+			}
+			width := percent(end) - percent(t.Started)
+			if width < 0.5 {
+				width = 0.5
+			}
+			return fmt.Sprintf("%.2f%%", width)
+		},
+	}
+	t, err := template.New("report").Funcs(funcs).Parse(reportHTMLTemplate)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, r)
+}
+
+const reportHTMLTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{or .Overview.Service.Name "Service"}} diagnostics bundle report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1, h2 { font-weight: 600; }
+h2 { margin-top: 2em; border-bottom: 1px solid #ddd; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { padding: 3px 8px; border-bottom: 1px solid #eee; text-align: left; vertical-align: top; }
+th.sortable { cursor: pointer; user-select: none; }
+th.sortable:after { content: " \2195"; color: #aaa; }
+.summary td:first-child { font-weight: 600; }
+.state-failed { color: #c62828; } .state-killed { color: #ef6c00; }
+.state-running { color: #2e7d32; } .state-starting { color: #1565c0; }
+.timeline { position: relative; height: 14px; width: 600px; background: #f5f5f5; }
+.bar { position: absolute; height: 14px; }
+.bar.state-failed { background: #e57373; } .bar.state-killed { background: #ffb74d; }
+.bar.state-running { background: #81c784; } .bar.state-starting { background: #64b5f6; }
+pre { background: #f7f7f7; padding: 8px; overflow-x: auto; font-size: 12px; }
+pre mark { background: #fff3c4; }
+code { font-size: 12px; }
+</style>
+</head>
+<body>
+<h1>{{or .Overview.Service.Name "Service"}} diagnostics bundle report</h1>
+<table class="summary">
+<tr><td>Bundle</td><td>{{.Overview.Bundle}}</td></tr>
+<tr><td>Package</td><td>{{or .Overview.Service.Package "unknown"}} {{.Overview.Service.Version}}</td></tr>
+<tr><td>Time range</td><td>{{time .Overview.From}} &ndash; {{time .Overview.To}}</td></tr>
+<tr><td>Tasks</td><td>{{.Overview.Tasks}}: {{range $state, $n := .Overview.States}}<span class="state-{{$state}}">{{$n}} {{$state}}</span> {{end}}</td></tr>
+<tr><td>Tasks without logs</td><td>{{len .Overview.TasksWithoutLogs}}</td></tr>
+<tr><td>Generated</td><td>{{time .GeneratedAt}}</td></tr>
+</table>
+
+<h2>Tasks</h2>
+<table id="tasks">
+<thead><tr>
+<th class="sortable">Name</th><th class="sortable">Pod type</th><th class="sortable">State</th>
+<th class="sortable">Started</th><th class="sortable">Ended</th><th class="sortable">Agent</th>
+<th class="sortable">Logs</th><th class="sortable">ID</th><th>Timeline</th>
+</tr></thead>
+<tbody>
+{{range .Tasks}}<tr>
+<td>{{.Name}}</td><td>{{.PodType}}</td><td class="state-{{.State}}">{{.State}}</td>
+<td data-sort="{{sortTime .Started}}">{{time .Started}}</td><td data-sort="{{sortTime .Ended}}">{{time .Ended}}</td>
+<td>{{.Agent}}</td><td>{{if .HasLogs}}yes{{else}}no{{end}}</td><td title="{{.DirName}}">{{shortID .ID}}</td>
+<td data-sort="{{sortTime .Started}}"><div class="timeline"><div class="bar state-{{.State}}" style="left: {{left .}}; width: {{width .}}" title="{{time .Started}} &ndash; {{time .Ended}}"></div></div></td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<h2>Restart history</h2>
+{{if not .Restarts}}<p>No restarts.</p>{{end}}
+{{range .Restarts}}<h3>{{.TaskName}} ({{len .Runs}} runs)</h3>
+<table>
+<tr><th>Started</th><th>Ended</th><th>State</th><th>Agent</th><th>ID</th><th>Timeline</th></tr>
+{{range .Runs}}<tr><td>{{time .Started}}</td><td>{{time .Ended}}</td><td class="state-{{.State}}">{{.State}}</td><td>{{.Agent}}</td><td title="{{.DirName}}">{{shortID .ID}}</td>
+<td><div class="timeline"><div class="bar state-{{.State}}" style="left: {{left .}}; width: {{width .}}"></div></div></td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Error findings</h2>
+{{if not .Findings}}<p>No errors found in the task logs.</p>{{end}}
+<table>
+{{if .Findings}}<tr><th>Lines</th><th>Tasks</th><th>First</th><th>Last</th><th>Signature</th><th></th></tr>{{end}}
+{{range $i, $f := .Findings}}<tr><td>{{$f.Count}}</td><td>{{len $f.Sources}}</td><td>{{time $f.First}}</td><td>{{time $f.Last}}</td>
+<td><code>{{$f.Template}}</code></td><td>{{if $f.Excerpts}}<a href="#excerpt-{{$i}}">excerpt</a>{{end}}</td></tr>
+{{end}}</table>
+
+{{if .Findings}}<h2>Log excerpts</h2>{{end}}
+{{range $i, $f := .Findings}}{{range $f.Excerpts}}{{$e := .}}<h3 id="excerpt-{{$i}}"><code>{{$f.Template}}</code></h3>
+<p><a href="{{.Path}}">{{.Path}}</a>, line {{.Line}}</p>
+<pre>{{range $j, $line := .Lines}}{{if eq $j $e.Example}}<mark>{{$line}}</mark>{{else}}{{$line}}{{end}}
+{{end}}</pre>
+{{end}}{{end}}
+
+<h2>Largest logs</h2>
+<table>
+<tr><th>Uncompressed</th><th>Stream</th><th>Path</th></tr>
+{{range .Overview.LargestLogs}}<tr><td>{{size .Uncompressed}}</td><td>{{.Stream}}</td><td><a href="{{.Path}}">{{.Path}}</a></td></tr>
+{{end}}</table>
+
+<script>
+document.querySelectorAll("th.sortable").forEach(function (th) {
+  th.addEventListener("click", function () {
+    var table = th.closest("table"), tbody = table.tBodies[0];
+    var index = Array.prototype.indexOf.call(th.parentNode.children, th);
+    var ascending = th.dataset.order !== "asc";
+    th.dataset.order = ascending ? "asc" : "desc";
+    var value = function (row) {
+      var cell = row.children[index];
+      return cell.dataset.sort !== undefined ? cell.dataset.sort : cell.textContent;
+    };
+    Array.prototype.slice.call(tbody.rows).sort(function (a, b) {
+      var result = value(a).localeCompare(value(b), undefined, {numeric: true});
+      return ascending ? result : -result;
+    }).forEach(function (row) { tbody.appendChild(row); });
+  });
+});
+</script>
+</body>
+</html>
+`
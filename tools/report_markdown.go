@@ -0,0 +1,205 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MarkdownOptions define the length of the Markdown report.
+type MarkdownOptions struct {
+	// MaxLength is the maximum length of the report in bytes, 0 means no limit. Findings and timeline rows
+	// which don't fit are omitted, the summary is always written.
+	MaxLength int
+	// ExcerptLines is the number of log lines shown for every finding.
+	ExcerptLines int
+}
+
+// DefaultMarkdownOptions fit into a comment of most ticketing systems.
+var DefaultMarkdownOptions = MarkdownOptions{MaxLength: 8000, ExcerptLines: 3}
+
+// markdownReserve is the length reserved for the notes about omitted items, at most one note per list.
+const markdownReserve = 100
+
+// WriteReportMarkdown writes a concise report for support tickets: the state counts, failing pod instances,
+// top findings with a few log lines and a compact timeline. Parts are added in this order while they fit
+// into the length limit.
+func WriteReportMarkdown(w io.Writer, r Report, opts MarkdownOptions) error {
+	b := &markdownBuilder{limit: opts.MaxLength}
+	o := r.Overview
+	name := o.Service.Name
+	if name == "" {
+		name = "Service"
+	}
+	b.write(fmt.Sprintf("### %v diagnostics bundle summary\n\n", name))
+	if o.Service.Package != "" || o.Service.Version != "" {
+		b.write(fmt.Sprintf("* **Package:** %v %v\n", o.Service.Package, o.Service.Version))
+	}
+	b.write(fmt.Sprintf("* **Time range:** %v – %v\n", markdownTime(o.From), markdownTime(o.To)))
+	states := make([]string, 0)
+	for _, state := range []string{StateStarting, StateRunning, StateKilled, StateFailed} {
+		states = append(states, fmt.Sprintf("%v %v", o.States[state], state))
+	}
+	b.write(fmt.Sprintf("* **Tasks:** %v (%v)\n", o.Tasks, strings.Join(states, ", ")))
+	b.write(fmt.Sprintf("* **Restarting instances:** %v, **tasks without logs:** %v\n",
+		len(o.Restarting), len(o.TasksWithoutLogs)))
+
+	failing := failingPods(r.Tasks)
+	if len(failing) != 0 {
+		rows := make([]string, 0, len(failing))
+		for _, p := range failing {
+			rows = append(rows, fmt.Sprintf("| %v | %v | %v | %v |\n",
+				markdownCell(p.pod), p.runs, p.failures, p.latest))
+		}
+		b.list("\n#### Failing pod instances\n\n| Pod | Runs | Failed | Latest state |\n|---|---|---|---|\n",
+			rows, "pod instances")
+	}
+
+	if len(r.Findings) != 0 {
+		blocks := make([]string, 0, len(r.Findings))
+		for _, f := range r.Findings {
+			block := fmt.Sprintf("* **%v** lines in %v tasks, %v – %v: `%v`\n", f.Count, len(f.Sources),
+				markdownTime(f.First), markdownTime(f.Last), strings.Replace(f.Template, "`", "'", -1))
+			if len(f.Excerpts) != 0 && opts.ExcerptLines > 0 {
+				lines := excerptLines(f.Excerpts[0], opts.ExcerptLines)
+				fence := markdownFence(lines)
+				block += "  " + fence + "\n"
+				for _, line := range lines {
+					block += "  " + line + "\n"
+				}
+				block += "  " + fence + "\n"
+			}
+			blocks = append(blocks, block)
+		}
+		b.list("\n#### Top findings\n\n", blocks, "findings")
+	}
+
+	rows := make([]string, 0, len(r.Tasks))
+	for _, t := range r.Tasks {
+		rows = append(rows, fmt.Sprintf("| %v | %v | %v | %v | %v |\n", markdownTime(t.Started),
+			markdownTime(t.Ended), markdownCell(t.Name), t.State, shortID(t.ID)))
+	}
+	b.list("\n#### Timeline\n\n| Started | Last change | Task | State | ID |\n|---|---|---|---|---|\n",
+		rows, "tasks")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+type markdownBuilder struct {
+	strings.Builder
+	limit int
+}
+
+func (b *markdownBuilder) fits(s string) bool {
+	return b.limit <= 0 || b.Len()+len(s)+markdownReserve <= b.limit
+}
+
+func (b *markdownBuilder) write(s string) {
+	b.WriteString(s)
+}
+
+// list writes the header and the items which fit, and a note about the omitted items. Only the note is written
+// if even the first item doesn't fit.
+func (b *markdownBuilder) list(header string, items []string, what string) {
+	if len(items) == 0 {
+		return
+	}
+	if !b.fits(header + items[0]) {
+		b.write(fmt.Sprintf("\n_%v %v omitted._\n", len(items), what))
+		return
+	}
+	b.write(header)
+	for i, item := range items {
+		if !b.fits(item) {
+			b.write(fmt.Sprintf("\n_%v more %v omitted._\n", len(items)-i, what))
+			return
+		}
+		b.write(item)
+	}
+}
+
+// markdownFence returns a code fence which is longer than any run of backticks in the lines,
+// so that the lines cannot close it.
+func markdownFence(lines []string) string {
+	longest := 0
+	for _, line := range lines {
+		run := 0
+		for _, c := range line {
+			if c != '`' {
+				run = 0
+				continue
+			}
+			run++
+			if run > longest {
+				longest = run
+			}
+		}
+	}
+	if longest < 3 {
+		return "```"
+	}
+	return strings.Repeat("`", longest+1)
+}
+
+type failingPod struct {
+	pod      string
+	runs     int
+	failures int
+	latest   string
+}
+
+// failingPods returns the pod instances with failed tasks, the most failures first.
+func failingPods(tasks []ReportTask) []failingPod {
+	pods := make(map[string]*failingPod)
+	latest := make(map[string]time.Time)
+	for _, t := range tasks {
+		p, ok := pods[t.Pod]
+		if !ok {
+			p = &failingPod{pod: t.Pod}
+			pods[t.Pod] = p
+		}
+		p.runs++
+		if t.State == StateFailed {
+			p.failures++
+		}
+		if !t.Started.Before(latest[t.Pod]) {
+			latest[t.Pod] = t.Started
+			p.latest = t.State
+		}
+	}
+	failing := make([]failingPod, 0)
+	for _, p := range pods {
+		if p.failures != 0 {
+			failing = append(failing, *p)
+		}
+	}
+	sort.Slice(failing, func(i, j int) bool {
+		if failing[i].failures != failing[j].failures {
+			return failing[i].failures > failing[j].failures
+		}
+		return failing[i].pod < failing[j].pod
+	})
+	return failing
+}
+
+// excerptLines returns n lines of the excerpt starting from the example line.
+func excerptLines(e LogExcerpt, n int) []string {
+	lines := e.Lines[e.Example:]
+	if len(lines) > n {
+		lines = lines[:n]
+	}
+	return lines
+}
+
+func markdownTime(t time.Time) string {
+	if t.IsZero() {
+		return "N/A"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func markdownCell(s string) string {
+	return strings.Replace(s, "|", `\|`, -1)
+}
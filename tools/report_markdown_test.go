@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteReportMarkdown(t *testing.T) {
+	start := time.Date(2020, 4, 16, 11, 0, 0, 0, time.UTC)
+	r := Report{Overview: Overview{Service: ServiceInfo{Name: "kafka"}, States: map[string]int{}}}
+	for i := 0; i < 50; i++ {
+		r.Tasks = append(r.Tasks, ReportTask{Name: fmt.Sprintf("kafka-%v-broker", i), ID: "id",
+			Pod: fmt.Sprintf("kafka-%v", i), State: StateFailed, Started: start.Add(time.Duration(i) * time.Minute)})
+	}
+	tests := []struct {
+		maxLength int
+		omitted   bool
+	}{
+		{0, false},
+		{1000, true},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		if err := WriteReportMarkdown(&b, r, MarkdownOptions{MaxLength: tt.maxLength}); err != nil {
+			t.Fatal(err)
+		}
+		if tt.maxLength > 0 && b.Len() > tt.maxLength {
+			t.Errorf("WriteReportMarkdown() wrote %v bytes, want at most %v", b.Len(), tt.maxLength)
+		}
+		if got := strings.Contains(b.String(), "omitted._"); got != tt.omitted {
+			t.Errorf("WriteReportMarkdown() with MaxLength %v has omitted note = %v, want %v",
+				tt.maxLength, got, tt.omitted)
+		}
+	}
+}
+
+func TestWriteReportMarkdown_noTasks(t *testing.T) {
+	var b bytes.Buffer
+	r := Report{Overview: Overview{States: map[string]int{}}}
+	if err := WriteReportMarkdown(&b, r, DefaultMarkdownOptions); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "Timeline") {
+		t.Errorf("WriteReportMarkdown() wrote a timeline without tasks:\n%v", b.String())
+	}
+}
+
+func TestWriteReportMarkdown_excerptFence(t *testing.T) {
+	r := Report{Overview: Overview{States: map[string]int{}}}
+	r.Findings = []Finding{{
+		LogTemplate: LogTemplate{Template: "ERROR Cannot parse <*>", Count: 1},
+		Excerpts: []LogExcerpt{{Lines: []string{
+			"ERROR Cannot parse ```json",
+			"  ````",
+		}}},
+	}}
+	var b bytes.Buffer
+	if err := WriteReportMarkdown(&b, r, DefaultMarkdownOptions); err != nil {
+		t.Fatal(err)
+	}
+	want := "  `````\n  ERROR Cannot parse ```json\n    ````\n  `````\n"
+	if !strings.Contains(b.String(), want) {
+		t.Errorf("WriteReportMarkdown() = %v, want the excerpt fenced with five backticks", b.String())
+	}
+}
+
+func Test_markdownFence(t *testing.T) {
+	tests := []struct {
+		lines []string
+		want  string
+	}{
+		{[]string{"no backticks"}, "```"},
+		{[]string{"`code` and ``more``"}, "```"},
+		{[]string{"```", "a ````` b"}, "``````"},
+	}
+	for _, tt := range tests {
+		if got := markdownFence(tt.lines); got != tt.want {
+			t.Errorf("markdownFence(%q) = %v, want %v", tt.lines, got, tt.want)
+		}
+	}
+}
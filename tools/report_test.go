@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_findExcerpt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task := Task{DirName: "task", DirNameAbsolute: filepath.Join(dir, DirNameTasks, "task")}
+	if err := os.MkdirAll(task.DirNameAbsolute, 0777); err != nil {
+		t.Fatal(err)
+	}
+	log := "1\n2\n3\nERROR boom\n5\n6\n7\n"
+	if err := ioutil.WriteFile(filepath.Join(task.DirNameAbsolute, "stderr"), []byte(log), 0666); err != nil {
+		t.Fatal(err)
+	}
+	got, err := findExcerpt(dir, task, "ERROR boom", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &LogExcerpt{TaskDirName: "task", Stream: StreamStderr, Path: filepath.Join(DirNameTasks, "task", "stderr"),
+		Line: 2, Lines: []string{"2", "3", "ERROR boom", "5", "6"}, Example: 2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findExcerpt() = %+v, want %+v", got, want)
+	}
+	if got, _ := findExcerpt(dir, task, "missing", 2); got != nil {
+		t.Errorf("findExcerpt() = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,235 @@
+package tools
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// LogDamage describes a part of a log file which couldn't be read.
+type LogDamage struct {
+	Path string `json:"path"`
+	// Offset in the file at which the damaged part starts.
+	Offset int64 `json:"offset"`
+	// Skipped is the number of bytes of the file which were skipped, -1 if the whole file was skipped.
+	Skipped int64  `json:"skipped"`
+	Error   string `json:"error"`
+}
+
+func (d LogDamage) String() string {
+	if d.Skipped < 0 {
+		return fmt.Sprintf("%v: skipped the whole file: %v", d.Path, d.Error)
+	}
+	if d.Skipped == 0 {
+		return fmt.Sprintf("%v: the data is cut at offset %v: %v", d.Path, d.Offset, d.Error)
+	}
+	return fmt.Sprintf("%v: skipped %v bytes at offset %v: %v", d.Path, d.Skipped, d.Offset, d.Error)
+}
+
+var damages = struct {
+	sync.Mutex
+	list []LogDamage
+	// seen are the recorded damages by path and offset, so that reading a file again, e.g.,
+	// on every request of the serve command, doesn't record the same damage twice.
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+func recordDamage(d LogDamage) {
+	damages.Lock()
+	defer damages.Unlock()
+	key := fmt.Sprintf("%v:%v", d.Path, d.Offset)
+	if damages.seen[key] {
+		return
+	}
+	damages.seen[key] = true
+	damages.list = append(damages.list, d)
+}
+
+// LogDamages returns the damaged parts of log files found since the program start.
+func LogDamages() []LogDamage {
+	damages.Lock()
+	defer damages.Unlock()
+	return append([]LogDamage{}, damages.list...)
+}
+
+// TakeLogDamages returns the damaged parts of log files found since the program start or the previous call
+// and forgets them, so that they are reported only once.
+func TakeLogDamages() []LogDamage {
+	damages.Lock()
+	defer damages.Unlock()
+	list := damages.list
+	damages.list = nil
+	damages.seen = make(map[string]bool)
+	return list
+}
+
+func isDamaged(path string) bool {
+	damages.Lock()
+	defer damages.Unlock()
+	for _, d := range damages.list {
+		if d.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// salvageReader decompresses a gzip file member by member. When a member is truncated or corrupt,
+// it returns what was decompressed before the error, records the damage and continues from the next
+// gzip member found in the file.
+type salvageReader struct {
+	path        string
+	f           *os.File
+	cr          *countingReader
+	zr          *gzip.Reader
+	memberStart int64
+	eof         bool
+}
+
+func newSalvageReader(path string) (*salvageReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &salvageReader{path: path, f: f, cr: newCountingReader(f, 0)}, nil
+}
+
+func (r *salvageReader) Read(p []byte) (int, error) {
+	for {
+		if r.eof {
+			return 0, io.EOF
+		}
+		if r.zr == nil {
+			if err := r.nextMember(); err != nil {
+				return 0, err
+			}
+		}
+		n, err := r.zr.Read(p)
+		if err == io.EOF {
+			r.zr = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			damageOffset := r.cr.offset
+			r.zr = nil
+			if err := r.resync(r.memberStart+1, damageOffset, err); err != nil && err != io.EOF {
+				return n, err
+			}
+			return n, nil
+		}
+		return n, nil
+	}
+}
+
+// nextMember starts reading the gzip member at the current offset.
+func (r *salvageReader) nextMember() error {
+	for {
+		if _, err := r.cr.br.Peek(1); err == io.EOF {
+			r.eof = true
+			return io.EOF
+		}
+		r.memberStart = r.cr.offset
+		zr, err := gzip.NewReader(r.cr)
+		if err == nil {
+			zr.Multistream(false)
+			r.zr = zr
+			return nil
+		}
+		if err := r.resync(r.memberStart+1, r.memberStart, err); err != nil {
+			return err
+		}
+	}
+}
+
+// resync records the damage and moves to the next gzip member after the from offset.
+// It returns io.EOF if there are no more members.
+func (r *salvageReader) resync(from int64, damageOffset int64, cause error) error {
+	next, err := findMagic(r.f, from)
+	if err != nil {
+		return err
+	}
+	end := next
+	if end < 0 {
+		info, err := r.f.Stat()
+		if err != nil {
+			return err
+		}
+		end = info.Size()
+	}
+	if damageOffset > end {
+		damageOffset = end
+	}
+	recordDamage(LogDamage{
+		Path:    r.path,
+		Offset:  damageOffset,
+		Skipped: end - damageOffset,
+		Error:   cause.Error(),
+	})
+	if next < 0 {
+		r.eof = true
+		return io.EOF
+	}
+	if _, err := r.f.Seek(next, io.SeekStart); err != nil {
+		return err
+	}
+	r.cr = newCountingReader(r.f, next)
+	return nil
+}
+
+func (r *salvageReader) Close() error {
+	return r.f.Close()
+}
+
+// findMagic returns the offset of the first gzip header at or after the from offset or -1.
+func findMagic(f *os.File, from int64) (int64, error) {
+	buf := make([]byte, 64*1024)
+	offset := from
+	for {
+		n, err := f.ReadAt(buf, offset)
+		if i := bytes.Index(buf[:n], gzipMagic); i >= 0 {
+			return offset + int64(i), nil
+		}
+		if err == io.EOF {
+			return -1, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		// The magic can be split between two chunks.
+		offset += int64(n - len(gzipMagic) + 1)
+	}
+}
+
+// countingReader counts the bytes consumed by the gzip reader. It implements io.ByteReader,
+// so the gzip reader doesn't read ahead and the offset is the exact end of the consumed data.
+type countingReader struct {
+	br     *bufio.Reader
+	offset int64
+}
+
+func newCountingReader(r io.Reader, offset int64) *countingReader {
+	return &countingReader{br: bufio.NewReaderSize(r, 64*1024), offset: offset}
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.br.Read(p)
+	c.offset += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.br.ReadByte()
+	if err == nil {
+		c.offset++
+	}
+	return b, err
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_salvageReader(t *testing.T) {
+	first := gzipMember(t, "first member\n")
+	second := gzipMember(t, "second member\n")
+	third := gzipMember(t, "third member\n")
+	corrupt := append([]byte{}, second...)
+	for i := 10; i < len(corrupt)-8; i++ {
+		corrupt[i] = 0xff
+	}
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		damaged bool
+	}{
+		{
+			"reads intact files with several members",
+			bytes.Join([][]byte{first, second, third}, nil),
+			"first member\nsecond member\nthird member\n",
+			false,
+		},
+		{
+			"reads what is left of a truncated file",
+			bytes.Join([][]byte{first, second[:len(second)-4]}, nil),
+			"first member\nsecond member\n",
+			true,
+		},
+		{
+			"skips a corrupt member",
+			bytes.Join([][]byte{first, corrupt, third}, nil),
+			"first member\nthird member\n",
+			true,
+		},
+		{
+			"skips garbage before a member",
+			bytes.Join([][]byte{[]byte("garbage"), first}, nil),
+			"first member\n",
+			true,
+		},
+	}
+	dir, err := ioutil.TempDir("", "sbun")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "stdout."+string(rune('1'+i))+".gz")
+			if err := ioutil.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			r, err := fileReader(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ioutil.ReadAll(r)
+			closeCloser(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("ReadAll() = %q, want %q", got, tt.want)
+			}
+			if isDamaged(path) != tt.damaged {
+				t.Errorf("isDamaged() = %v, want %v", isDamaged(path), tt.damaged)
+			}
+		})
+	}
+}
+
+func Test_recordDamage(t *testing.T) {
+	_ = TakeLogDamages()
+	d := LogDamage{Path: "stdout.1.gz", Offset: 10, Error: "unexpected EOF"}
+	recordDamage(d)
+	recordDamage(d)
+	recordDamage(LogDamage{Path: "stdout.1.gz", Offset: 20, Error: "unexpected EOF"})
+	if got := TakeLogDamages(); len(got) != 2 {
+		t.Errorf("TakeLogDamages() = %v, want 2 damages", got)
+	}
+	if got := TakeLogDamages(); len(got) != 0 {
+		t.Errorf("TakeLogDamages() = %v after taking the damages, want none", got)
+	}
+}
@@ -0,0 +1,344 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// DirNameScheduler is the directory with the scheduler logs in some bundles.
+const DirNameScheduler = "scheduler"
+
+// Kinds of scheduler events.
+const (
+	// EventOffer is an offer evaluation, the outcome is "passed" or "failed".
+	EventOffer = "offer"
+	// EventPlanStep is a plan step status change, the outcome is the new status.
+	EventPlanStep = "plan-step"
+	// EventReconciliation is a task reconciliation, the outcome is "started" or "finished".
+	EventReconciliation = "reconciliation"
+	// EventRegistration is a framework registration, the outcome is "registered", "re-registered" or "disconnected".
+	EventRegistration = "registration"
+)
+
+// SchedulerEvent is an event found in the scheduler log.
+type SchedulerEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	Outcome string    `json:"outcome"`
+	// Subject is the pod instance requirement for offers and the step for plan step changes.
+	Subject string `json:"subject"`
+	// Reason is the first failed evaluation stage for offers and the previous status for plan step changes.
+	Reason string `json:"reason"`
+	Source string `json:"source"`
+	Line   string `json:"line"`
+}
+
+// SchedulerTimelineEntry is a series of consecutive events with the same kind, outcome, subject and reason.
+type SchedulerTimelineEntry struct {
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+	Kind    string    `json:"kind"`
+	Outcome string    `json:"outcome"`
+	Subject string    `json:"subject"`
+	Reason  string    `json:"reason"`
+	Count   int       `json:"count"`
+}
+
+// SchedulerCount is the number of events with the same kind, outcome and reason.
+type SchedulerCount struct {
+	Kind    string `json:"kind"`
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason"`
+	Count   int    `json:"count"`
+}
+
+// SchedulerAnalysis is the summary of the scheduler logs.
+type SchedulerAnalysis struct {
+	Sources  []string                 `json:"sources"`
+	Counts   []SchedulerCount         `json:"counts"`
+	Timeline []SchedulerTimelineEntry `json:"timeline"`
+	Events   []SchedulerEvent         `json:"-"`
+}
+
+// INFO  [main] OfferEvaluator:evaluate(90): message
+var schedulerPrefixRegexp = regexp.MustCompile(`^\s*(?:[A-Z]+\s+)?(?:\[[^\]]*\]\s+)?(?:[\w.$]+:[\w$<>]+\(\d+\):\s+)?`)
+
+var (
+	// Evaluating up to 3 offers for kafka-0:[broker]
+	offerRequirementRegexp = regexp.MustCompile(
+		`(?i)^evaluating (?:up to )?\d+ offers? for (?:pod(?: instance requirement)?:?\s*)?(.+)$`)
+	// Offer 1, 3ea1...-O123: failed 2 of 14 evaluation stages
+	offerOutcomeRegexp = regexp.MustCompile(`(?i)^offer \d+(?:,\s*\S+)?:\s*(failed|passed)\b`)
+	// fail(PlacementRuleEvaluationStage): hostname:UNIQUE is not satisfied
+	offerFailureRegexp = regexp.MustCompile(`(?i)^\s*(?:-\s*)?fail(?:ed)?(?:\s*\(([^)]*)\))?:?\s*(.*)$`)
+	// deploy:[kafka-0:[broker]] : changed status from: PENDING to: PREPARED
+	stepStatusRegexp   = regexp.MustCompile(`^(.+?)\s*:?\s*changed status from:?\s*(\w+)\s+to:?\s*(\w+)`)
+	reconcileEndRegexp = regexp.MustCompile(`(?i)reconciliation (?:is |has )?(?:complete|completed|finished|done)\b`)
+	reconcileRegexp    = regexp.MustCompile(`(?i)\b(?:start|trigger)\w*\b.*\breconcil`)
+	reregisteredRegexp = regexp.MustCompile(`(?i)\bre-?registered\b`)
+	registeredRegexp   = regexp.MustCompile(`(?i)\bregistered (?:framework|with)\b`)
+	// Disconnected from Master, shutting down.
+	disconnectedRegexp = regexp.MustCompile(`(?i)^disconnected from (?:the )?master\b`)
+)
+
+// SchedulerTasks returns the tasks which don't follow the <pod type>-<index>-<task> naming convention,
+// i.e., the scheduler tasks.
+func SchedulerTasks(tasks []Task) []Task {
+	schedulers := make([]Task, 0)
+	for _, t := range tasks {
+		if t.PodIndex() < 0 {
+			schedulers = append(schedulers, t)
+		}
+	}
+	return schedulers
+}
+
+// AnalyzeSchedulerLogs parses the log streams of the scheduler tasks and the files in the scheduler directory
+// of the bundle and summarizes the offer evaluations, plan step changes, reconciliations and registrations.
+func AnalyzeSchedulerLogs(bundlePath string, schedulerTasks []Task) (SchedulerAnalysis, error) {
+	p := &schedulerLogParser{}
+	a := SchedulerAnalysis{Sources: make([]string, 0)}
+	for _, task := range schedulerTasks {
+		for _, stream := range LogStreamNames() {
+			paths, err := TaskLogFiles(task, stream)
+			if err != nil {
+				return a, err
+			}
+			if len(paths) == 0 {
+				continue
+			}
+			r, err := OpenTaskLog(task, stream)
+			if err != nil {
+				return a, err
+			}
+			source := filepath.Join(task.DirName, stream)
+			err = p.parse(r, source)
+			closeCloser(r)
+			if err != nil {
+				return a, fmt.Errorf("cannot read %v: %v", source, err)
+			}
+			a.Sources = append(a.Sources, source)
+		}
+	}
+	schedulerDir := filepath.Join(bundlePath, DirNameScheduler)
+	if dirExists(schedulerDir) {
+		paths, err := schedulerLogFiles(schedulerDir)
+		if err != nil {
+			return a, err
+		}
+		for _, path := range paths {
+			source, _ := filepath.Rel(bundlePath, path)
+			r, err := fileReader(path)
+			if err != nil {
+				return a, err
+			}
+			err = p.parse(r, source)
+			closeCloser(r)
+			if err != nil {
+				return a, fmt.Errorf("cannot read %v: %v", source, err)
+			}
+			a.Sources = append(a.Sources, source)
+		}
+	}
+	sort.SliceStable(p.events, func(i, j int) bool {
+		return p.events[i].Time.Before(p.events[j].Time)
+	})
+	a.Events = p.events
+	a.Counts = countSchedulerEvents(p.events)
+	a.Timeline = schedulerTimeline(p.events)
+	return a, nil
+}
+
+// schedulerLogFiles returns the files of the configured log streams in the directory and its subdirectories.
+// Like in TaskLogFiles, the file written by Concat is used if it exists in a directory, otherwise the rotations
+// are used, the oldest first. Other files, like configuration dumps or binaries, are not logs.
+func schedulerLogFiles(dir string) ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		for _, s := range logStreams {
+			rotations, allPath, err := s.files(path)
+			if err != nil {
+				return err
+			}
+			if allPath != "" {
+				paths = append(paths, allPath)
+			} else {
+				paths = append(paths, rotations...)
+			}
+		}
+		return nil
+	})
+	return paths, err
+}
+
+type schedulerLogParser struct {
+	events []SchedulerEvent
+	// requirement is the pod instance requirement of the offers being evaluated.
+	requirement string
+	// offer is the index of the last failed offer evaluation event which doesn't have a reason yet, or -1.
+	offer int
+	time  time.Time
+}
+
+func (p *schedulerLogParser) parse(r io.Reader, source string) error {
+	p.requirement, p.offer, p.time = "", -1, time.Time{}
+	return forEachLine(r, func(line string) {
+		t, rest, ok := ParseLineTime(line)
+		if !ok {
+			// Continuation of a multi-line message, e.g., the offer evaluation stages.
+			if p.offer >= 0 {
+				if tokens := offerFailureRegexp.FindStringSubmatch(line); tokens != nil {
+					p.events[p.offer].Reason = strings.TrimPrefix(tokens[1]+": "+strings.TrimSpace(tokens[2]), ": ")
+					p.offer = -1
+				}
+			}
+			return
+		}
+		p.time, p.offer = t, -1
+		p.parseMessage(schedulerPrefixRegexp.ReplaceAllString(rest, ""), line, source)
+	})
+}
+
+func (p *schedulerLogParser) parseMessage(message string, line string, source string) {
+	add := func(kind string, outcome string, subject string, reason string) {
+		p.events = append(p.events, SchedulerEvent{p.time, kind, outcome, subject, reason, source, line})
+	}
+	if tokens := offerRequirementRegexp.FindStringSubmatch(message); tokens != nil {
+		p.requirement = strings.TrimSpace(tokens[1])
+		return
+	}
+	if tokens := offerOutcomeRegexp.FindStringSubmatch(message); tokens != nil {
+		outcome := strings.ToLower(tokens[1])
+		add(EventOffer, outcome, p.requirement, "")
+		if outcome == "failed" {
+			p.offer = len(p.events) - 1
+		}
+		return
+	}
+	if tokens := stepStatusRegexp.FindStringSubmatch(message); tokens != nil {
+		add(EventPlanStep, tokens[3], strings.TrimSpace(tokens[1]), tokens[2])
+		return
+	}
+	switch {
+	case reconcileEndRegexp.MatchString(message):
+		add(EventReconciliation, "finished", "", "")
+	case reconcileRegexp.MatchString(message):
+		add(EventReconciliation, "started", "", "")
+	case reregisteredRegexp.MatchString(message):
+		add(EventRegistration, "re-registered", "", "")
+	case registeredRegexp.MatchString(message):
+		add(EventRegistration, "registered", "", "")
+	case disconnectedRegexp.MatchString(message):
+		add(EventRegistration, "disconnected", "", "")
+	}
+}
+
+func countSchedulerEvents(events []SchedulerEvent) []SchedulerCount {
+	counts := make(map[SchedulerCount]int)
+	for _, e := range events {
+		counts[SchedulerCount{Kind: e.Kind, Outcome: e.Outcome, Reason: e.Reason}]++
+	}
+	result := make([]SchedulerCount, 0, len(counts))
+	for c, n := range counts {
+		c.Count = n
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Outcome+a.Reason < b.Outcome+b.Reason
+	})
+	return result
+}
+
+// schedulerTimeline collapses consecutive events with the same kind, outcome, subject and reason,
+// e.g., repeated failures to place a pod for the same reason.
+func schedulerTimeline(events []SchedulerEvent) []SchedulerTimelineEntry {
+	timeline := make([]SchedulerTimelineEntry, 0)
+	for _, e := range events {
+		if n := len(timeline); n != 0 {
+			last := &timeline[n-1]
+			if last.Kind == e.Kind && last.Outcome == e.Outcome && last.Subject == e.Subject && last.Reason == e.Reason {
+				last.Last = e.Time
+				last.Count++
+				continue
+			}
+		}
+		timeline = append(timeline, SchedulerTimelineEntry{e.Time, e.Time, e.Kind, e.Outcome, e.Subject, e.Reason, 1})
+	}
+	return timeline
+}
+
+// WriteSchedulerAnalysis prints the event counts and the timeline in the text or json format.
+// If kinds is not empty, only the events of these kinds are printed.
+func WriteSchedulerAnalysis(w io.Writer, a SchedulerAnalysis, format string, kinds []string) error {
+	if len(kinds) != 0 {
+		events := make([]SchedulerEvent, 0)
+		for _, e := range a.Events {
+			if containsKind(kinds, e.Kind) {
+				events = append(events, e)
+			}
+		}
+		a.Events = events
+		a.Counts = countSchedulerEvents(events)
+		a.Timeline = schedulerTimeline(events)
+	}
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(a)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	_, _ = fmt.Fprintf(w, "Sources: %v\n\n", strings.Join(a.Sources, ", "))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "EVENT\tOUTCOME\tCOUNT\tREASON")
+	for _, c := range a.Counts {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", c.Kind, c.Outcome, c.Count, c.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "FIRST\tLAST\tCOUNT\tEVENT\tOUTCOME\tSUBJECT\tREASON")
+	for _, e := range a.Timeline {
+		last := ""
+		if e.Count > 1 {
+			last = printTime(e.Last)
+		}
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			printTime(e.First), last, e.Count, e.Kind, e.Outcome, e.Subject, e.Reason)
+	}
+	return tw.Flush()
+}
+
+func containsKind(kinds []string, kind string) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
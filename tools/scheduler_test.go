@@ -0,0 +1,84 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchedulerLogParser(t *testing.T) {
+	log := `INFO  2020-04-16 11:00:01,000 [main] Scheduler:registered(120): Registered framework with frameworkId: F1
+INFO  2020-04-16 11:01:00,000 [offers] OfferEvaluator:evaluate(90): Evaluating up to 2 offers for kafka-0:[broker]
+INFO  2020-04-16 11:01:00,100 [offers] OfferEvaluator:evaluate(150): Offer 1, O1: failed 1 of 14 evaluation stages:
+  pass(ResourceEvaluationStage): cpus 1.0 ok
+  fail(PlacementRuleEvaluationStage): hostname:UNIQUE is not satisfied
+INFO  2020-04-16 11:01:00,200 [offers] OfferEvaluator:evaluate(150): Offer 2, O2: passed all 14 evaluation stages
+INFO  2020-04-16 11:02:00,000 [plan] Step:set(8): deploy:[kafka-0:[broker]] : changed status from: PENDING to: STARTING
+INFO  2020-04-16 11:03:00,000 [main] TaskReconciler:start(60): Triggering explicit reconciliation of 3 tasks
+INFO  2020-04-16 11:03:01,000 [main] FrameworkScheduler:reregistered(140): Re-registered with master: M1
+INFO  2020-04-16 11:04:00,000 [zk] ConnectionStateManager:postState(228): State change: SUSPENDED, disconnected
+INFO  2020-04-16 11:04:01,000 [zk] ClientCnxn:run(1158): Session timed out, attempting reconnect, Disconnected
+ERROR 2020-04-16 11:05:00,000 [Thread-1] FrameworkScheduler:disconnected(160): Disconnected from Master, shutting down.
+`
+	p := &schedulerLogParser{}
+	if err := p.parse(strings.NewReader(log), "stdout"); err != nil {
+		t.Fatal(err)
+	}
+	want := []SchedulerEvent{
+		{Kind: EventRegistration, Outcome: "registered"},
+		{Kind: EventOffer, Outcome: "failed", Subject: "kafka-0:[broker]",
+			Reason: "PlacementRuleEvaluationStage: hostname:UNIQUE is not satisfied"},
+		{Kind: EventOffer, Outcome: "passed", Subject: "kafka-0:[broker]"},
+		{Kind: EventPlanStep, Outcome: "STARTING", Subject: "deploy:[kafka-0:[broker]]", Reason: "PENDING"},
+		{Kind: EventReconciliation, Outcome: "started"},
+		{Kind: EventRegistration, Outcome: "re-registered"},
+		{Kind: EventRegistration, Outcome: "disconnected"},
+	}
+	if len(p.events) != len(want) {
+		t.Fatalf("parsed %v events, want %v: %+v", len(p.events), len(want), p.events)
+	}
+	for i, w := range want {
+		e := p.events[i]
+		if e.Kind != w.Kind || e.Outcome != w.Outcome || e.Subject != w.Subject || e.Reason != w.Reason {
+			t.Errorf("event %v = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
+func Test_schedulerLogFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-scheduler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	writeTestFiles(t, dir, map[string][]byte{
+		"stdout":          []byte("new\n"),
+		"stdout.1.gz":     gzipMember(t, "old\n"),
+		"stdout.2":        []byte("older\n"),
+		"stderr":          []byte("error\n"),
+		"task/stderr.1":   []byte("concatenated error\n"),
+		"task/stderr_all": []byte("concatenated error\n"),
+		"env.json":        []byte("{}"),
+		"scheduler.jar":   {0xca, 0xfe, 0xba, 0xbe},
+		"svc.yml":         []byte("name: kafka\n"),
+	})
+	paths, err := schedulerLogFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(paths))
+	for _, path := range paths {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"stdout.2", "stdout.1.gz", "stdout", "stderr", "task/stderr_all"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schedulerLogFiles() = %v, want %v", got, want)
+	}
+}
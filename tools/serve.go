@@ -0,0 +1,372 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultLogPageSize is the number of log lines returned by the log API if the limit is not set.
+const DefaultLogPageSize = 500
+
+// maxLogPageSize limits the log lines returned by the log API at once.
+const maxLogPageSize = 5000
+
+// LogPage is a page of a task log returned by the log API.
+type LogPage struct {
+	Task   string `json:"task"`
+	Stream string `json:"stream"`
+	Search string `json:"search"`
+	// Offset is the index of the first line of the page among all the lines or, if Search is set,
+	// among the matching lines.
+	Offset int           `json:"offset"`
+	Total  int           `json:"total"`
+	Lines  []LogPageLine `json:"lines"`
+}
+
+// LogPageLine is a log line with its number in the log, starting from 1.
+type LogPageLine struct {
+	Number int    `json:"number"`
+	Text   string `json:"text"`
+}
+
+// Timeline is the tasks ordered by the start time with the time range of the bundle.
+type Timeline struct {
+	From  time.Time    `json:"from"`
+	To    time.Time    `json:"to"`
+	Tasks []ReportTask `json:"tasks"`
+}
+
+// Server serves the web UI and the JSON API for browsing the bundle:
+//
+//	GET /api/tasks?filter=state=failed&latest=true   tasks matching the filter conditions, see ParseTaskFilter
+//	GET /api/tasks/<task>/logs?stream=stdout&offset=0&limit=500&search=error
+//	                                                 a page of the task log, all the rotations are read
+//	GET /api/timeline                                tasks ordered by the start time
+//	GET /api/plans                                   plans of the scheduler
+//	GET /api/streams                                 configured log stream names
+//
+// The task is a task ID, ID prefix, name or directory name, see FindTask.
+type Server struct {
+	bundlePath string
+	tasks      []Task
+	mux        *http.ServeMux
+	mu         sync.Mutex
+	// logIndexes are the indexes of the task logs by the task directory name and the stream.
+	logIndexes map[string]*logIndex
+}
+
+// NewServer creates the server for the bundle with the given tasks.
+func NewServer(bundlePath string, tasks []Task) *Server {
+	s := &Server{bundlePath: bundlePath, tasks: tasks, mux: http.NewServeMux(),
+		logIndexes: make(map[string]*logIndex)}
+	s.mux.HandleFunc("/", s.serveUI)
+	s.mux.HandleFunc("/api/tasks", s.serveTasks)
+	s.mux.HandleFunc("/api/tasks/", s.serveTaskLogs)
+	s.mux.HandleFunc("/api/timeline", s.serveTimeline)
+	s.mux.HandleFunc("/api/plans", s.servePlans)
+	s.mux.HandleFunc("/api/streams", s.serveStreams)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %v is not allowed", r.Method))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) serveUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = io.WriteString(w, serveHTML)
+}
+
+func (s *Server) serveTasks(w http.ResponseWriter, r *http.Request) {
+	filter, err := ParseTaskFilter(r.URL.Query()["filter"])
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	tasks := filter.Filter(s.tasks)
+	if r.URL.Query().Get("latest") == "true" {
+		tasks = LatestRunPerInstance(tasks)
+	}
+	writeAPIResult(w, reportTasks(tasks))
+}
+
+func (s *Server) serveTaskLogs(w http.ResponseWriter, r *http.Request) {
+	tokens := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/tasks/"), "/")
+	if len(tokens) != 2 || tokens[1] != "logs" {
+		writeAPIError(w, http.StatusNotFound, fmt.Errorf("unknown API path %v", r.URL.Path))
+		return
+	}
+	task, err := FindTask(s.tasks, tokens[0])
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, err)
+		return
+	}
+	q := r.URL.Query()
+	stream := q.Get("stream")
+	if stream == "" {
+		stream = StreamStdout
+	}
+	offset, limit := 0, DefaultLogPageSize
+	for name, dst := range map[string]*int{"offset": &offset, "limit": &limit} {
+		if v := q.Get(name); v != "" {
+			if *dst, err = strconv.Atoi(v); err != nil || *dst < 0 {
+				writeAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid %v %q", name, v))
+				return
+			}
+		}
+	}
+	if limit == 0 || limit > maxLogPageSize {
+		limit = maxLogPageSize
+	}
+	idx, err := s.logIndex(task, stream)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	page, err := readLogPage(idx, task, stream, q.Get("search"), offset, limit)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResult(w, page)
+}
+
+// readLogPage reads the lines of the task log from offset to offset+limit. If search is not empty,
+// only the lines containing it, ignoring case, are counted. Files before the page are skipped with the help
+// of the index and reading stops at the end of the page.
+func readLogPage(idx *logIndex, task Task, stream string, search string, offset int, limit int) (LogPage, error) {
+	page := LogPage{Task: task.DirName, Stream: stream, Search: search, Offset: offset, Lines: make([]LogPageLine, 0)}
+	search = strings.ToLower(search)
+	counts, err := idx.counts(search)
+	if err != nil {
+		return page, fmt.Errorf("cannot read logs of the task %v: %v", task.DirName, err)
+	}
+	for _, c := range counts {
+		page.Total += c
+	}
+	errStop := errors.New("stop")
+	// matched is the number of matching lines before the current one, n is the number of the current line.
+	matched, n := 0, 0
+	for i, f := range idx.files {
+		if matched >= offset+limit {
+			break
+		}
+		if matched+counts[i] <= offset {
+			matched += counts[i]
+			n += f.lines
+			continue
+		}
+		err := forEachFileLine(f.path, func(line string) error {
+			n++
+			if search != "" && !strings.Contains(strings.ToLower(line), search) {
+				return nil
+			}
+			if matched >= offset {
+				page.Lines = append(page.Lines, LogPageLine{n, line})
+			}
+			matched++
+			if matched >= offset+limit {
+				return errStop
+			}
+			return nil
+		})
+		if err != nil && err != errStop {
+			return page, fmt.Errorf("cannot read logs of the task %v: %v", task.DirName, err)
+		}
+	}
+	return page, nil
+}
+
+// maxCachedSearches limits the number of searches whose counts are cached per log.
+const maxCachedSearches = 16
+
+// logIndex caches the number of lines in every file of a task log, so that pages of the log don't require
+// reading the whole log. The numbers of lines matching recent searches are cached too.
+type logIndex struct {
+	files []logFileIndex
+	mu    sync.Mutex
+	// matches are the numbers of matching lines in every file by the lower case search.
+	matches map[string][]int
+}
+
+type logFileIndex struct {
+	path    string
+	size    int64
+	modTime time.Time
+	lines   int
+}
+
+// logIndex returns the index of the task log, it is rebuilt if the log files have changed.
+func (s *Server) logIndex(task Task, stream string) (*logIndex, error) {
+	paths, err := TaskLogFiles(task, stream)
+	if err != nil {
+		return nil, err
+	}
+	key := task.DirName + "/" + stream
+	s.mu.Lock()
+	idx := s.logIndexes[key]
+	s.mu.Unlock()
+	if idx != nil && idx.isFresh(paths) {
+		return idx, nil
+	}
+	idx = &logIndex{files: make([]logFileIndex, 0, len(paths)), matches: make(map[string][]int)}
+	for _, path := range paths {
+		f := logFileIndex{path: path, size: -1}
+		if info, err := os.Stat(path); err == nil {
+			f.size, f.modTime = info.Size(), info.ModTime()
+		}
+		if f.lines, err = countFileLines(path, ""); err != nil {
+			return nil, fmt.Errorf("cannot read logs of the task %v: %v", task.DirName, err)
+		}
+		idx.files = append(idx.files, f)
+	}
+	s.mu.Lock()
+	s.logIndexes[key] = idx
+	s.mu.Unlock()
+	return idx, nil
+}
+
+// isFresh reports whether the index was built from the same versions of the files.
+func (idx *logIndex) isFresh(paths []string) bool {
+	if len(paths) != len(idx.files) {
+		return false
+	}
+	for i, f := range idx.files {
+		if f.path != paths[i] {
+			return false
+		}
+		info, err := os.Stat(f.path)
+		if err != nil {
+			if f.size != -1 {
+				return false
+			}
+			continue
+		}
+		if info.Size() != f.size || !info.ModTime().Equal(f.modTime) {
+			return false
+		}
+	}
+	return true
+}
+
+// counts returns the number of lines containing the lower case search in every file, or the number of all
+// the lines if search is empty.
+func (idx *logIndex) counts(search string) ([]int, error) {
+	if search == "" {
+		counts := make([]int, 0, len(idx.files))
+		for _, f := range idx.files {
+			counts = append(counts, f.lines)
+		}
+		return counts, nil
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if counts, ok := idx.matches[search]; ok {
+		return counts, nil
+	}
+	counts := make([]int, 0, len(idx.files))
+	for _, f := range idx.files {
+		n, err := countFileLines(f.path, search)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, n)
+	}
+	if len(idx.matches) >= maxCachedSearches {
+		idx.matches = make(map[string][]int)
+	}
+	idx.matches[search] = counts
+	return counts, nil
+}
+
+// countFileLines returns the number of lines of the log file containing the lower case search,
+// all the lines are counted if search is empty.
+func countFileLines(path string, search string) (int, error) {
+	n := 0
+	err := forEachFileLine(path, func(line string) error {
+		if search == "" || strings.Contains(strings.ToLower(line), search) {
+			n++
+		}
+		return nil
+	})
+	return n, err
+}
+
+// forEachFileLine calls fn for every line of the log file. A file which cannot be opened has no lines
+// and is recorded, see LogDamages.
+func forEachFileLine(path string, fn func(line string) error) error {
+	r, err := fileReader(path)
+	if err != nil {
+		recordDamage(LogDamage{Path: path, Skipped: -1, Error: err.Error()})
+		return nil
+	}
+	defer closeCloser(r)
+	return forEachLineErr(r, fn)
+}
+
+func (s *Server) serveTimeline(w http.ResponseWriter, _ *http.Request) {
+	t := Timeline{Tasks: reportTasks(s.tasks)}
+	sort.SliceStable(t.Tasks, func(i, j int) bool {
+		return t.Tasks[i].Started.Before(t.Tasks[j].Started)
+	})
+	for _, task := range t.Tasks {
+		if !task.Started.IsZero() && (t.From.IsZero() || task.Started.Before(t.From)) {
+			t.From = task.Started
+		}
+		if task.Ended.After(t.To) {
+			t.To = task.Ended
+		}
+	}
+	writeAPIResult(w, t)
+}
+
+func (s *Server) servePlans(w http.ResponseWriter, _ *http.Request) {
+	plans, err := FindPlans(s.bundlePath)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResult(w, plans)
+}
+
+func (s *Server) serveStreams(w http.ResponseWriter, _ *http.Request) {
+	writeAPIResult(w, LogStreamNames())
+}
+
+func reportTasks(tasks []Task) []ReportTask {
+	result := make([]ReportTask, 0, len(tasks))
+	for _, t := range tasks {
+		result = append(result, newReportTask(t))
+	}
+	return result
+}
+
+func writeAPIResult(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
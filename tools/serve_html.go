@@ -0,0 +1,203 @@
+package tools
+
+// serveHTML is the web UI of the serve command. It uses only the JSON API, see Server.
+const serveHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>sbun</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #222; }
+nav { background: #263238; padding: 8px 16px; }
+nav a { color: #eceff1; margin-right: 16px; text-decoration: none; cursor: pointer; }
+nav a.active { font-weight: 600; border-bottom: 2px solid #eceff1; }
+main { padding: 16px; }
+table { border-collapse: collapse; font-size: 13px; }
+th, td { padding: 3px 8px; border-bottom: 1px solid #eee; text-align: left; vertical-align: top; }
+tr.clickable { cursor: pointer; } tr.clickable:hover { background: #f5f5f5; }
+input[type=text] { width: 400px; }
+.error { color: #c62828; }
+.state-failed { color: #c62828; } .state-killed { color: #ef6c00; }
+.state-running { color: #2e7d32; } .state-starting { color: #1565c0; }
+.timeline { position: relative; height: 14px; width: 600px; background: #f5f5f5; }
+.bar { position: absolute; height: 14px; }
+.bar.state-failed { background: #e57373; } .bar.state-killed { background: #ffb74d; }
+.bar.state-running { background: #81c784; } .bar.state-starting { background: #64b5f6; }
+pre { background: #f7f7f7; padding: 8px; overflow-x: auto; font-size: 12px; }
+.number { color: #999; user-select: none; display: inline-block; min-width: 5em; }
+.attention { color: #c62828; font-weight: 600; }
+</style>
+</head>
+<body>
+<nav>
+<a data-view="tasks">Tasks</a><a data-view="timeline">Timeline</a><a data-view="plans">Plans</a>
+</nav>
+<main id="main"></main>
+<script>
+"use strict";
+var main = document.getElementById("main");
+
+function el(tag, attrs, children) {
+  var e = document.createElement(tag);
+  Object.keys(attrs || {}).forEach(function (k) { e.setAttribute(k, attrs[k]); });
+  (children || []).forEach(function (c) {
+    e.appendChild(typeof c === "string" ? document.createTextNode(c) : c);
+  });
+  return e;
+}
+
+function api(path) {
+  return fetch(path).then(function (r) {
+    return r.json().then(function (body) {
+      if (!r.ok) { throw new Error(body.error); }
+      return body;
+    });
+  });
+}
+
+function showError(e) {
+  main.appendChild(el("p", {"class": "error"}, [e.message]));
+}
+
+function time(t) {
+  return !t || t.indexOf("0001-") === 0 ? "N/A" : t.replace("T", " ").replace("Z", "");
+}
+
+function showTasks(params) {
+  var filter = el("input", {type: "text", placeholder: "state=failed pod-type=kafka name=kafka-*-broker"});
+  filter.value = params.get("filter") || "";
+  var latest = el("input", {type: "checkbox"});
+  latest.checked = params.get("latest") === "true";
+  var table = el("table");
+  var apply = function () {
+    var q = new URLSearchParams();
+    filter.value.split(/\s+/).filter(Boolean).forEach(function (c) { q.append("filter", c); });
+    if (latest.checked) { q.set("latest", "true"); }
+    history.replaceState(null, "", "#tasks?" + q.toString());
+    table.innerHTML = "";
+    api("/api/tasks?" + q.toString()).then(function (tasks) {
+      table.appendChild(el("tr", {}, ["Name", "Pod type", "State", "Started", "Last change", "Agent", "Logs", "ID"]
+        .map(function (h) { return el("th", {}, [h]); })));
+      tasks.forEach(function (t) {
+        var row = el("tr", {"class": "clickable", title: t.dirName}, [
+          el("td", {}, [t.name]), el("td", {}, [t.podType]), el("td", {"class": "state-" + t.state}, [t.state]),
+          el("td", {}, [time(t.started)]), el("td", {}, [time(t.ended)]), el("td", {}, [t.agent]),
+          el("td", {}, [t.hasLogs ? "yes" : "no"]), el("td", {}, [t.id])]);
+        row.addEventListener("click", function () { location.hash = "logs?task=" + encodeURIComponent(t.dirName); });
+        table.appendChild(row);
+      });
+    }).catch(showError);
+  };
+  filter.addEventListener("keyup", function (e) { if (e.key === "Enter") { apply(); } });
+  latest.addEventListener("change", apply);
+  main.appendChild(el("p", {}, ["Filter: ", filter, " ", el("label", {}, [latest, " latest run per instance"])]));
+  main.appendChild(table);
+  apply();
+}
+
+function showLogs(params) {
+  var task = params.get("task");
+  var limit = 500;
+  var offset = parseInt(params.get("offset") || "0", 10);
+  var stream = el("select");
+  var search = el("input", {type: "text", placeholder: "search"});
+  search.value = params.get("search") || "";
+  var info = el("span");
+  var prev = el("button", {}, ["Previous"]), next = el("button", {}, ["Next"]);
+  var pre = el("pre");
+  var load = function () {
+    var q = new URLSearchParams({stream: stream.value, offset: offset, limit: limit, search: search.value});
+    history.replaceState(null, "", "#logs?task=" + encodeURIComponent(task) + "&" + q.toString());
+    api("/api/tasks/" + encodeURIComponent(task) + "/logs?" + q.toString()).then(function (page) {
+      pre.innerHTML = "";
+      page.lines.forEach(function (l) {
+        pre.appendChild(el("span", {"class": "number"}, [String(l.number)]));
+        pre.appendChild(document.createTextNode(l.text + "\n"));
+      });
+      var last = Math.min(page.offset + page.lines.length, page.total);
+      info.textContent = " " + (page.total ? page.offset + 1 : 0) + "-" + last + " of " + page.total +
+        (page.search ? " matching lines " : " lines ");
+      prev.disabled = page.offset === 0;
+      next.disabled = last >= page.total;
+    }).catch(showError);
+  };
+  prev.addEventListener("click", function () { offset = Math.max(0, offset - limit); load(); });
+  next.addEventListener("click", function () { offset += limit; load(); });
+  search.addEventListener("keyup", function (e) { if (e.key === "Enter") { offset = 0; load(); } });
+  stream.addEventListener("change", function () { offset = 0; load(); });
+  main.appendChild(el("h2", {}, [task]));
+  main.appendChild(el("p", {}, [stream, " ", search, info, prev, next]));
+  main.appendChild(pre);
+  api("/api/streams").then(function (streams) {
+    streams.forEach(function (s) { stream.appendChild(el("option", {value: s}, [s])); });
+    stream.value = params.get("stream") || streams[0];
+    load();
+  }).catch(showError);
+}
+
+function showTimeline() {
+  api("/api/timeline").then(function (tl) {
+    var from = Date.parse(tl.from), span = Date.parse(tl.to) - from;
+    var percent = function (t) { return span > 0 && time(t) !== "N/A" ? (Date.parse(t) - from) / span * 100 : 0; };
+    main.appendChild(el("p", {}, [time(tl.from) + " – " + time(tl.to)]));
+    var table = el("table");
+    tl.tasks.forEach(function (t) {
+      var end = t.state === "running" ? tl.to : t.ended;
+      var width = Math.max(percent(end) - percent(t.started), 0.5);
+      var bar = el("div", {"class": "bar state-" + t.state, title: time(t.started) + " – " + time(t.ended),
+        style: "left: " + percent(t.started) + "%; width: " + width + "%"});
+      var row = el("tr", {"class": "clickable"}, [el("td", {}, [t.name]),
+        el("td", {"class": "state-" + t.state}, [t.state]), el("td", {}, [el("div", {"class": "timeline"}, [bar])])]);
+      row.addEventListener("click", function () { location.hash = "logs?task=" + encodeURIComponent(t.dirName); });
+      table.appendChild(row);
+    });
+    main.appendChild(table);
+  }).catch(showError);
+}
+
+function showPlans() {
+  var attention = function (status) { return ["PENDING", "ERROR", "WAITING"].indexOf((status || "").toUpperCase()) >= 0; };
+  var status = function (s) { return el("td", attention(s) ? {"class": "attention"} : {}, [s || ""]); };
+  api("/api/plans").then(function (plans) {
+    if (!plans.length) { main.appendChild(el("p", {}, ["No plans found."])); }
+    plans.forEach(function (p) {
+      main.appendChild(el("h2", {}, [p.name + " (" + p.file + ")"]));
+      (p.errors || []).forEach(function (e) { main.appendChild(el("p", {"class": "attention"}, [e])); });
+      var table = el("table", {}, [el("tr", {}, [el("th", {}, ["Phase / step"]), el("th", {}, ["Strategy"]),
+        el("th", {}, ["Status"]), el("th", {}, ["Message"])])]);
+      table.appendChild(el("tr", {}, [el("td", {}, [el("b", {}, [p.name])]), el("td", {}, [p.strategy || ""]),
+        status(p.status), el("td")]));
+      (p.phases || []).forEach(function (ph) {
+        table.appendChild(el("tr", {}, [el("td", {}, ["  " + ph.name]), el("td", {}, [ph.strategy || ""]),
+          status(ph.status), el("td")]));
+        (ph.steps || []).forEach(function (s) {
+          table.appendChild(el("tr", {}, [el("td", {}, ["    " + s.name]), el("td"),
+            status(s.status), el("td", {}, [s.message || ""])]));
+        });
+      });
+      main.appendChild(table);
+    });
+  }).catch(showError);
+}
+
+function route() {
+  var hash = location.hash.substring(1) || "tasks";
+  var i = hash.indexOf("?");
+  var view = i < 0 ? hash : hash.substring(0, i);
+  var params = new URLSearchParams(i < 0 ? "" : hash.substring(i + 1));
+  main.innerHTML = "";
+  document.querySelectorAll("nav a").forEach(function (a) {
+    a.className = a.dataset.view === view ? "active" : "";
+  });
+  ({tasks: showTasks, logs: showLogs, timeline: showTimeline, plans: showPlans}[view] || showTasks)(params);
+}
+
+document.querySelectorAll("nav a").forEach(function (a) {
+  a.addEventListener("click", function () { location.hash = a.dataset.view; });
+});
+window.addEventListener("hashchange", route);
+route();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,113 @@
+package tools
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestServer_logs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task := Task{ID: "id", DirName: "task", DirNameAbsolute: filepath.Join(dir, DirNameTasks, "task")}
+	if err := os.MkdirAll(task.DirNameAbsolute, 0777); err != nil {
+		t.Fatal(err)
+	}
+	log := "INFO 1\nERROR 2\nINFO 3\nerror 4\nINFO 5\n"
+	if err := ioutil.WriteFile(filepath.Join(task.DirNameAbsolute, "stdout"), []byte(log), 0666); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(dir, []Task{task})
+	tests := []struct {
+		url    string
+		status int
+		total  int
+		lines  []LogPageLine
+	}{
+		{"/api/tasks/id/logs?offset=1&limit=2", http.StatusOK, 5, []LogPageLine{{2, "ERROR 2"}, {3, "INFO 3"}}},
+		{"/api/tasks/id/logs?search=Error&offset=1", http.StatusOK, 2, []LogPageLine{{4, "error 4"}}},
+		{"/api/tasks/id/logs?offset=10", http.StatusOK, 5, []LogPageLine{}},
+		{"/api/tasks/id/logs?limit=-1", http.StatusBadRequest, 0, nil},
+		{"/api/tasks/missing/logs", http.StatusNotFound, 0, nil},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if rec.Code != tt.status {
+			t.Errorf("GET %v status = %v, want %v", tt.url, rec.Code, tt.status)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		page := LogPage{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != tt.total || !reflect.DeepEqual(page.Lines, tt.lines) {
+			t.Errorf("GET %v = %v lines of %v, want %v lines of %v", tt.url, page.Lines, page.Total, tt.lines, tt.total)
+		}
+	}
+}
+
+func TestServer_logRotations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task := Task{ID: "id", DirName: "task", DirNameAbsolute: filepath.Join(dir, DirNameTasks, "task")}
+	writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{
+		"stdout.2.gz": gzipMember(t, "INFO 1\nERROR 2\n"),
+		"stdout.1":    []byte("INFO 3\nINFO 4\nERROR 5\n"),
+		"stdout":      []byte("INFO 6\nERROR 7\n"),
+	})
+	server := NewServer(dir, []Task{task})
+	tests := []struct {
+		search string
+		offset int
+		limit  int
+		total  int
+		lines  []LogPageLine
+	}{
+		{"", 0, 1, 7, []LogPageLine{{1, "INFO 1"}}},
+		{"", 3, 2, 7, []LogPageLine{{4, "INFO 4"}, {5, "ERROR 5"}}},
+		{"", 4, 10, 7, []LogPageLine{{5, "ERROR 5"}, {6, "INFO 6"}, {7, "ERROR 7"}}},
+		{"", 7, 10, 7, []LogPageLine{}},
+		{"error", 1, 1, 3, []LogPageLine{{5, "ERROR 5"}}},
+		{"error", 1, 5, 3, []LogPageLine{{5, "ERROR 5"}, {7, "ERROR 7"}}},
+	}
+	idx, err := server.logIndex(task, StreamStdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		page, err := readLogPage(idx, task, StreamStdout, tt.search, tt.offset, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Total != tt.total || !reflect.DeepEqual(page.Lines, tt.lines) {
+			t.Errorf("readLogPage(%q, %v, %v) = %v lines of %v, want %v lines of %v",
+				tt.search, tt.offset, tt.limit, page.Lines, page.Total, tt.lines, tt.total)
+		}
+	}
+	if cached, err := server.logIndex(task, StreamStdout); err != nil || cached != idx {
+		t.Errorf("logIndex() didn't return the cached index, error = %v", err)
+	}
+	writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{"stdout": []byte("INFO 6\nERROR 7\nINFO 8\n")})
+	updated, err := server.logIndex(task, StreamStdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated == idx || updated.files[2].lines != 3 {
+		t.Errorf("logIndex() didn't rebuild the index of the changed log")
+	}
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"regexp"
+)
+
+// ERROR, FATAL, panic:, java.io.IOException, ValueError
+var errorLineRegexp = regexp.MustCompile(`\b(ERROR|FATAL|SEVERE|CRITICAL)\b|\bpanic:|[A-Za-z](Exception|Error)\b`)
+
+// IsErrorLine reports whether the log message reports an error.
+func IsErrorLine(message string) bool {
+	return errorLineRegexp.MatchString(message)
+}
+
+// NewErrorSignatureMiner returns a template miner which mines only the error lines.
+// The templates of error lines are called error signatures.
+func NewErrorSignatureMiner(similarity float64) *TemplateMiner {
+	m := NewTemplateMiner(similarity)
+	m.accept = IsErrorLine
+	return m
+}
+
+// MineErrorSignatures mines error signatures from all the log streams of the tasks. The Sources field of
+// the signatures has the directory names of the affected tasks.
+func MineErrorSignatures(tasks []Task) ([]LogTemplate, error) {
+	return mineTasks(NewErrorSignatureMiner(DefaultSimilarity), tasks, LogStreamNames())
+}
+
+// MineErrorSignatureGroups mines error signatures from several groups of tasks, e.g., from several bundles.
+// All the groups are mined with a single miner, so that the same error gets the same signature in every group
+// regardless of the order of the lines. It returns the signatures with the statistics of every group
+// in the order of the groups.
+func MineErrorSignatureGroups(groups [][]Task) ([][]LogTemplate, error) {
+	m := NewErrorSignatureMiner(DefaultSimilarity)
+	for i, tasks := range groups {
+		m.group = i
+		for _, task := range tasks {
+			for _, stream := range LogStreamNames() {
+				if err := mineTaskLog(m, task, stream); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	signatures := make([][]LogTemplate, 0, len(groups))
+	for i := range groups {
+		signatures = append(signatures, m.groupTemplates(i))
+	}
+	return signatures, nil
+}
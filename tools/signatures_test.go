@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsErrorLine(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"ERROR Cannot connect", true},
+		{"FATAL Disk is full", true},
+		{"panic: runtime error: index out of range", true},
+		{"Caused by: java.io.IOException: Broken pipe", true},
+		{"ValueError: invalid literal", true},
+		{"INFO Errors: 0", false},
+		{"INFO Connected", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			if got := IsErrorLine(tt.message); got != tt.want {
+				t.Errorf("IsErrorLine() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMineErrorSignatureGroups(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-signatures")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	task, err := parseTaskDirName("starting_20200416T110000-running_20200416T110100__kafka-0-broker__kafka-0-broker__a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The same task directory in two bundles, e.g., with different log rotations.
+	groups := make([][]Task, 0, 2)
+	for i, stdout := range []string{
+		"ERROR Cannot reach the leader of the topic orders\nINFO ok\n",
+		"ERROR Cannot reach the leader of the topic events\nERROR Cannot reach the leader of the topic events\n",
+	} {
+		task.DirNameAbsolute = filepath.Join(dir, string(rune('a'+i)), task.DirName)
+		writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{"stdout": []byte(stdout)})
+		groups = append(groups, []Task{task})
+	}
+	signatures, err := MineErrorSignatureGroups(groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]LogTemplate{
+		{{
+			Template: "ERROR Cannot reach the leader of the topic <*>",
+			Count:    1,
+			Example:  "ERROR Cannot reach the leader of the topic orders",
+			Source:   task.DirName,
+			Sources:  []string{task.DirName},
+		}},
+		{{
+			Template: "ERROR Cannot reach the leader of the topic <*>",
+			Count:    2,
+			Example:  "ERROR Cannot reach the leader of the topic events",
+			Source:   task.DirName,
+			Sources:  []string{task.DirName},
+		}},
+	}
+	if !reflect.DeepEqual(signatures, want) {
+		t.Errorf("MineErrorSignatureGroups() = %+v, want %+v", signatures, want)
+	}
+}
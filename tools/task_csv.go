@@ -7,13 +7,11 @@ import (
 	"time"
 )
 
-func WriteCsv(bundlePath string, writer *os.File) error {
-	tasks, err := FindTasks(bundlePath)
-	if err != nil {
-		return fmt.Errorf("cannot write CSV: %v", err.Error())
-	}
+// WriteCsv writes the tasks of the bundles in the CSV format. If withBundle is true, the first column
+// is the bundle path, so tasks of several bundles can be told apart.
+func WriteCsv(bundlePaths []string, withBundle bool, writer *os.File) error {
 	csvWriter := csv.NewWriter(writer)
-	err = csvWriter.Write([]string{
+	header := []string{
 		"Name",
 		"Staring",
 		"Running",
@@ -22,9 +20,37 @@ func WriteCsv(bundlePath string, writer *os.File) error {
 		"ID",
 		"Has Logs",
 		"Dir Name",
-	})
+		"Agent",
+		"Agent ID",
+		"Framework ID",
+		"Resources",
+		"Container",
+		"Reason",
+		"Message",
+	}
+	if withBundle {
+		header = append([]string{"Bundle"}, header...)
+	}
+	if err := csvWriter.Write(header); err != nil {
+		return fmt.Errorf("cannot write to the CSV output: %v", err.Error())
+	}
+	for _, bundlePath := range bundlePaths {
+		tasks, err := FindTasks(bundlePath)
+		if err != nil {
+			return fmt.Errorf("cannot write CSV: %v", err.Error())
+		}
+		if err := writeCsvTasks(csvWriter, bundlePath, withBundle, tasks); err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func writeCsvTasks(csvWriter *csv.Writer, bundlePath string, withBundle bool, tasks []Task) error {
 	for _, t := range tasks {
-		err = csvWriter.Write([]string{
+		m := t.mesos()
+		record := []string{
 			t.Name,
 			printTime(t.Staring),
 			printTime(t.Running),
@@ -33,12 +59,21 @@ func WriteCsv(bundlePath string, writer *os.File) error {
 			t.ID,
 			fmt.Sprintf("%v", t.HasLogs),
 			t.DirName,
-		})
-		if err != nil {
+			m.AgentHost,
+			m.AgentID,
+			m.FrameworkID,
+			m.FormatResources(),
+			m.Container,
+			m.Reason,
+			m.Message,
+		}
+		if withBundle {
+			record = append([]string{bundlePath}, record...)
+		}
+		if err := csvWriter.Write(record); err != nil {
 			return fmt.Errorf("cannot write to the CSV output: %v", err.Error())
 		}
 	}
-	csvWriter.Flush()
 	return nil
 }
 
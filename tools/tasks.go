@@ -6,22 +6,20 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const DirNameTasks = "tasks"
 
-// starting_20200416T110149-running_20200416T112050-killed_20200416T114052__kafka-2-broker__06e119a6-b6bb-4dae-8229-799cdf54c752
-var taskIDRegexp = regexp.MustCompile(`__(.+)__(.+)$`)
 var taskStatusRegexp = regexp.MustCompile(`(failed|starting|running|killed)_([0-9T]*)`)
 
 // stdout.1.gz, stdout.gz, stdout, stdout.1
 var stdoutRegexp = regexp.MustCompile(`^stdout(\.[0-9]+)?(\.gz)?$`)
 var stderrRegexp = regexp.MustCompile(`^stderr(\.[0-9]*)?(\.gz)?$`)
 
-// stdout_all, stderr_all, stdout_all.gz, stderr_all.gz
-var stdAllRegexp = regexp.MustCompile(`^(stderr|stdout)_all(\.gz)?$`)
-
 type Task struct {
 	ID              string
 	Name            string
@@ -32,35 +30,11 @@ type Task struct {
 	Killed          time.Time
 	Failed          time.Time
 	HasLogs         bool
-}
-
-func parseTaskDirName(dirName string) (Task, error) {
-	task := Task{}
-	idTokens := taskIDRegexp.FindStringSubmatch(dirName)
-	if len(idTokens) != 3 {
-		return task, fmt.Errorf("cannot parse ID and name for task: %v", dirName)
-	}
-	task.ID = idTokens[2]
-	task.Name = idTokens[1]
-	statusTokens := taskStatusRegexp.FindAllStringSubmatch(dirName, -1)
-	if len(statusTokens) == 0 {
-		return task, fmt.Errorf("cannot parse statuses for task: %v", dirName)
-	}
-	statuses := make(map[string]time.Time)
-	for _, token := range statusTokens {
-		// Mon Jan 2 15:04:05 -0700 MST 2006
-		t, err := time.Parse("20060102T150405", token[2])
-		if err != nil {
-			return task, err
-		}
-		statuses[token[1]] = t
-	}
-	task.DirName = dirName
-	task.Staring = statuses["starting"]
-	task.Running = statuses["running"]
-	task.Killed = statuses["killed"]
-	task.Failed = statuses["failed"]
-	return task, nil
+	// Mesos is the Mesos state of the task if it is known in advance. If it is nil, MesosState reads
+	// the Mesos state files of the bundle when it is needed for the first time.
+	Mesos *MesosTask
+	// bundlePath is the bundle the task was found in, it is empty for tasks which are not from a bundle.
+	bundlePath string
 }
 
 func FindTasks(bundlePath string) ([]Task, error) {
@@ -77,12 +51,14 @@ func FindTasks(bundlePath string) ([]Task, error) {
 		task, err := parseTaskDirName(f.Name())
 		if err != nil {
 			_, _ = fmt.Fprintf(os.Stderr, "WARNING: cannot parse the directory name \"%v\". "+
-				"If you know that this directory was created by the service diagnostics bundle tool, "+
-				"please, create the issue https://github.com/adyatlov/sbun/issues: %v\n",
+				"If the directory follows another naming convention, please, configure a task directory name "+
+				"parser for it, see the config command. If you know that this directory was created by the service "+
+				"diagnostics bundle tool, please, create the issue https://github.com/adyatlov/sbun/issues: %v\n",
 				f.Name(), err.Error())
 			continue
 		}
 		task.DirNameAbsolute = filepath.Join(tasksDir, task.DirName)
+		task.bundlePath = bundlePath
 		task.HasLogs = hasLogs(task.DirNameAbsolute)
 		tasks = append(tasks, task)
 	}
@@ -92,26 +68,165 @@ func FindTasks(bundlePath string) ([]Task, error) {
 	return tasks, nil
 }
 
+// hasLogs reports whether the task directory has files of any configured log stream. Only the configured
+// stream directories are checked, so logs in other sandbox subdirectories don't count.
 func hasLogs(taskDir string) bool {
-	var has bool
-	err := filepath.Walk(taskDir, func(path string, info os.FileInfo, err error) error {
+	for _, s := range logStreams {
+		dirs, err := s.taskDirs(taskDir)
 		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Cannot walk into path %v: %v", path, err)
-			return nil
+			_, _ = fmt.Fprintf(os.Stderr, "Cannot find log directories: %v\n", err)
+			continue
+		}
+		for _, dir := range dirs {
+			paths, allPath, err := s.files(dir)
+			if err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Cannot find logs: %v\n", err)
+				continue
+			}
+			if allPath != "" || len(paths) != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindTask finds the task by its ID, ID prefix, name or directory name. If there are several
+// tasks with the given name, it returns the latest one.
+func FindTask(tasks []Task, query string) (Task, error) {
+	var byPrefix, byName []Task
+	for _, task := range tasks {
+		if task.ID == query || task.DirName == query {
+			return task, nil
 		}
-		if info.IsDir() {
-			return nil
+		if strings.HasPrefix(task.ID, query) {
+			byPrefix = append(byPrefix, task)
 		}
-		if stdoutRegexp.MatchString(info.Name()) ||
-			stderrRegexp.MatchString(info.Name()) ||
-			stdAllRegexp.MatchString(info.Name()) {
-			has = true
-			return filepath.SkipDir
+		if task.Name == query {
+			byName = append(byName, task)
 		}
-		return nil
-	})
+	}
+	// SDK task IDs start with the task name, so names are checked before ID prefixes.
+	if len(byName) != 0 {
+		latest := byName[0]
+		for _, task := range byName[1:] {
+			if task.Started().After(latest.Started()) {
+				latest = task
+			}
+		}
+		return latest, nil
+	}
+	if len(byPrefix) > 1 {
+		return Task{}, fmt.Errorf("task ID prefix %q is ambiguous, it matches %v tasks", query, len(byPrefix))
+	}
+	if len(byPrefix) == 0 {
+		return Task{}, fmt.Errorf("cannot find task %q", query)
+	}
+	return byPrefix[0], nil
+}
+
+// kafka-2-broker: pod type "kafka", pod index 2, task "broker"
+var podTaskRegexp = regexp.MustCompile(`^(.+)-([0-9]+)-([^-].*)$`)
+
+// PodType returns the type of the pod the task belongs to, e.g., "kafka" for the "kafka-2-broker" task.
+// If the task name doesn't follow the <pod type>-<index>-<task> convention, e.g., it is a scheduler task,
+// the task name is returned.
+func (t Task) PodType() string {
+	tokens := podTaskRegexp.FindStringSubmatch(t.Name)
+	if tokens == nil {
+		return t.Name
+	}
+	return tokens[1]
+}
+
+// PodIndex returns the index of the pod instance, e.g., 2 for the "kafka-2-broker" task, or -1 if
+// the task name doesn't follow the <pod type>-<index>-<task> convention.
+func (t Task) PodIndex() int {
+	tokens := podTaskRegexp.FindStringSubmatch(t.Name)
+	if tokens == nil {
+		return -1
+	}
+	n, err := strconv.Atoi(tokens[2])
 	if err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "Cannot walk: %v", err)
+		return -1
+	}
+	return n
+}
+
+// PodInstance returns the name of the pod instance, e.g., "kafka-2" for the "kafka-2-broker" task.
+// If the task name doesn't follow the <pod type>-<index>-<task> convention, the task name is returned.
+func (t Task) PodInstance() string {
+	tokens := podTaskRegexp.FindStringSubmatch(t.Name)
+	if tokens == nil {
+		return t.Name
+	}
+	return tokens[1] + "-" + tokens[2]
+}
+
+// Task states in the order they happen.
+const (
+	StateStarting = "starting"
+	StateRunning  = "running"
+	StateKilled   = "killed"
+	StateFailed   = "failed"
+)
+
+// State returns the latest known state of the task. If several states have the same timestamp,
+// the one which happens later in the task lifecycle is returned.
+func (t Task) State() string {
+	state, latest := "", time.Time{}
+	for _, s := range []struct {
+		name string
+		t    time.Time
+	}{
+		{StateStarting, t.Staring},
+		{StateRunning, t.Running},
+		{StateKilled, t.Killed},
+		{StateFailed, t.Failed},
+	} {
+		if !s.t.IsZero() && !s.t.Before(latest) {
+			state, latest = s.name, s.t
+		}
+	}
+	return state
+}
+
+// Started returns the earliest known timestamp of the task.
+func (t Task) Started() time.Time {
+	started := time.Time{}
+	for _, s := range []time.Time{t.Staring, t.Running, t.Killed, t.Failed} {
+		if !s.IsZero() && (started.IsZero() || s.Before(started)) {
+			started = s
+		}
+	}
+	return started
+}
+
+// CountRestarts returns the number of restarts of every task name, i.e., the number of runs minus one.
+// Task names are unique per pod instance, e.g., kafka-2-broker.
+func CountRestarts(tasks []Task) map[string]int {
+	restarts := make(map[string]int)
+	for _, t := range tasks {
+		restarts[t.Name] = 0
+	}
+	for _, t := range restartedTasks(tasks) {
+		restarts[t.Name]++
+	}
+	return restarts
+}
+
+// restartedTasks returns the tasks which are not the first run of their task name.
+func restartedTasks(tasks []Task) []Task {
+	byName := make(map[string][]Task)
+	for _, t := range tasks {
+		byName[t.Name] = append(byName[t.Name], t)
+	}
+	restarted := make([]Task, 0)
+	for _, runs := range byName {
+		sort.Slice(runs, func(i, j int) bool {
+			return runs[i].Started().Before(runs[j].Started())
+		})
+		restarted = append(restarted, runs[1:]...)
 	}
-	return has
+	return restarted
 }
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	templateWildcard = "<*>"
+	// DefaultSimilarity is the minimal share of equal tokens for a line to be matched with a template.
+	DefaultSimilarity = 0.5
+)
+
+// Masks are applied in order, e.g., UUIDs are masked before they can be recognized as several numbers.
+var templateMasks = []struct {
+	r    *regexp.Regexp
+	mask string
+}{
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<UUID>"},
+	{regexp.MustCompile(`\b\d{1,3}\.\d{1,3}\.\d{1,3}\.\d{1,3}(:\d+)?\b`), "<IP>"},
+	{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<HEX>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b`), "<ID>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`), "<ID>"},
+	{regexp.MustCompile(`[-+]?\b\d+(\.\d+)?\b`), "<NUM>"},
+}
+
+// LogTemplate is a group of log lines which differ only in variable parts like numbers, IDs and IPs.
+type LogTemplate struct {
+	Template string `json:"template"`
+	Count    int    `json:"count"`
+	Example  string `json:"example"`
+	// Source of the example line, usually a task directory name.
+	Source string `json:"source"`
+	// Sources are all the sources of the lines in the order they were seen.
+	Sources []string `json:"sources"`
+	// First and Last are zero if none of the lines had a timestamp.
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+
+	tokens []string
+	// groups are the statistics of the lines of every group, see TemplateMiner.group.
+	groups map[int]*LogTemplate
+}
+
+// TemplateMiner groups log lines into templates using a simplified Drain algorithm:
+// lines are masked, split into tokens and grouped by the number of tokens and the first token,
+// then each line joins the most similar template in its group or creates a new one.
+type TemplateMiner struct {
+	similarity float64
+	groups     map[string][]*LogTemplate
+	templates  []*LogTemplate
+	// accept selects the messages to mine, all messages are mined if it is nil.
+	accept func(message string) bool
+	// group of the added lines, e.g., a bundle index. Lines of all the groups are mined together,
+	// so that the same line gets the same template in every group, see groupTemplates.
+	group int
+}
+
+func NewTemplateMiner(similarity float64) *TemplateMiner {
+	return &TemplateMiner{
+		similarity: similarity,
+		groups:     make(map[string][]*LogTemplate),
+	}
+}
+
+// AddFrom adds all the lines from the reader. Lines without a timestamp, like stack trace lines,
+// get the timestamp of the closest preceding line.
+func (m *TemplateMiner) AddFrom(r io.Reader, source string) error {
+	var last time.Time
+	return forEachLine(r, func(line string) {
+		t, message, ok := ParseLineTime(line)
+		if ok {
+			last = t
+		}
+		m.Add(message, line, source, last)
+	})
+}
+
+// Add adds the message of the log line to the matching template; the line itself is kept as an example.
+func (m *TemplateMiner) Add(message string, line string, source string, t time.Time) {
+	if m.accept != nil && !m.accept(message) {
+		return
+	}
+	tokens := strings.Fields(maskMessage(message))
+	if len(tokens) == 0 {
+		return
+	}
+	key := groupKey(tokens)
+	var best *LogTemplate
+	bestScore := -1.0
+	for _, tmpl := range m.groups[key] {
+		score := similarity(tmpl.tokens, tokens)
+		if score > bestScore {
+			best, bestScore = tmpl, score
+		}
+	}
+	if best == nil || bestScore < m.similarity {
+		best = &LogTemplate{tokens: tokens, groups: make(map[int]*LogTemplate)}
+		m.groups[key] = append(m.groups[key], best)
+		m.templates = append(m.templates, best)
+	} else {
+		for i, token := range best.tokens {
+			if token != tokens[i] {
+				best.tokens[i] = templateWildcard
+			}
+		}
+	}
+	best.record(line, source, t)
+	g, ok := best.groups[m.group]
+	if !ok {
+		g = &LogTemplate{}
+		best.groups[m.group] = g
+	}
+	g.record(line, source, t)
+}
+
+// record updates the statistics of the template with the line. The first line is kept as an example.
+func (t *LogTemplate) record(line string, source string, ts time.Time) {
+	if t.Count == 0 {
+		t.Example, t.Source = line, source
+	}
+	t.Count++
+	if len(t.Sources) == 0 || t.Sources[len(t.Sources)-1] != source {
+		t.addSource(source)
+	}
+	if !ts.IsZero() {
+		if t.First.IsZero() || ts.Before(t.First) {
+			t.First = ts
+		}
+		if ts.After(t.Last) {
+			t.Last = ts
+		}
+	}
+}
+
+func (t *LogTemplate) addSource(source string) {
+	for _, s := range t.Sources {
+		if s == source {
+			return
+		}
+	}
+	t.Sources = append(t.Sources, source)
+}
+
+// Templates returns the mined templates ordered by frequency, the most frequent first.
+func (m *TemplateMiner) Templates() []LogTemplate {
+	templates := make([]LogTemplate, 0, len(m.templates))
+	for _, tmpl := range m.templates {
+		templates = append(templates, tmpl.export(*tmpl))
+	}
+	sortTemplates(templates)
+	return templates
+}
+
+// groupTemplates returns the templates with the statistics of the lines of the group, ordered by frequency.
+// Templates without lines of the group are omitted.
+func (m *TemplateMiner) groupTemplates(group int) []LogTemplate {
+	templates := make([]LogTemplate, 0)
+	for _, tmpl := range m.templates {
+		if g, ok := tmpl.groups[group]; ok {
+			templates = append(templates, tmpl.export(*g))
+		}
+	}
+	sortTemplates(templates)
+	return templates
+}
+
+// export returns the statistics with the template text.
+func (t *LogTemplate) export(stats LogTemplate) LogTemplate {
+	stats.Template = strings.Join(t.tokens, " ")
+	stats.tokens = nil
+	stats.groups = nil
+	return stats
+}
+
+func sortTemplates(templates []LogTemplate) {
+	sort.SliceStable(templates, func(i, j int) bool {
+		return templates[i].Count > templates[j].Count
+	})
+}
+
+// MineTaskTemplates mines templates from the given streams of the tasks.
+func MineTaskTemplates(tasks []Task, streams []string, similarity float64) ([]LogTemplate, error) {
+	return mineTasks(NewTemplateMiner(similarity), tasks, streams)
+}
+
+func mineTasks(m *TemplateMiner, tasks []Task, streams []string) ([]LogTemplate, error) {
+	for _, task := range tasks {
+		for _, stream := range streams {
+			if err := mineTaskLog(m, task, stream); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return m.Templates(), nil
+}
+
+func mineTaskLog(m *TemplateMiner, task Task, stream string) error {
+	r, err := OpenTaskLog(task, stream)
+	if err != nil {
+		return err
+	}
+	defer closeCloser(r)
+	if err := m.AddFrom(r, task.DirName); err != nil {
+		return fmt.Errorf("cannot read %v of the task %v: %v", stream, task.DirName, err)
+	}
+	return nil
+}
+
+// WriteTemplates prints top templates as a table. If top is 0, it prints all the templates.
+func WriteTemplates(w io.Writer, templates []LogTemplate, top int) error {
+	if top > 0 && len(templates) > top {
+		templates = templates[:top]
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "COUNT\tFIRST\tLAST\tTEMPLATE")
+	for _, t := range templates {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", t.Count, printTime(t.First), printTime(t.Last), t.Template)
+		_, _ = fmt.Fprintf(tw, "\t\t\t  e.g. %v\n", t.Example)
+	}
+	return tw.Flush()
+}
+
+func maskMessage(message string) string {
+	for _, m := range templateMasks {
+		message = m.r.ReplaceAllString(message, m.mask)
+	}
+	return message
+}
+
+func groupKey(tokens []string) string {
+	first := tokens[0]
+	if strings.ContainsAny(first, "<>") {
+		first = templateWildcard
+	}
+	return fmt.Sprintf("%v %v", len(tokens), first)
+}
+
+// similarity returns the share of positions at which the tokens are equal or the template has a wildcard.
+func similarity(template []string, tokens []string) float64 {
+	equal := 0
+	for i, token := range template {
+		if token == tokens[i] || token == templateWildcard {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(template))
+}
+
+// forEachLine calls fn for every line of the reader without the line ending. Unlike bufio.Scanner,
+// it doesn't limit the length of a line.
+func forEachLine(r io.Reader, fn func(line string)) error {
+	return forEachLineErr(r, func(line string) error {
+		fn(line)
+		return nil
+	})
+}
+
+// forEachLineErr is like forEachLine, but stops and returns the error when fn fails.
+func forEachLineErr(r io.Reader, fn func(line string) error) error {
+	br := bufio.NewReaderSize(r, 64*1024)
+	for {
+		line, err := br.ReadString('\n')
+		if len(line) > 0 {
+			if err := fn(strings.TrimRight(line, "\r\n")); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
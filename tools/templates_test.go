@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_maskMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{
+			"masks numbers",
+			"Processed 15 records in 0.25 seconds",
+			"Processed <NUM> records in <NUM> seconds",
+		},
+		{
+			"masks IPs with ports",
+			"Connected to 10.0.1.15:9092",
+			"Connected to <IP>",
+		},
+		{
+			"masks UUIDs",
+			"Task 06e119a6-b6bb-4dae-8229-799cdf54c752 is running",
+			"Task <UUID> is running",
+		},
+		{
+			"masks hexadecimal IDs",
+			"Session 0x1717f2a3c0d0001 expired, id 5eb63bbbe01eeed093cb22bb8f5acdc3",
+			"Session <HEX> expired, id <ID>",
+		},
+		{
+			"does not mask words",
+			"Broker is ready",
+			"Broker is ready",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maskMessage(tt.message); got != tt.want {
+				t.Errorf("maskMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplateMiner_AddFrom(t *testing.T) {
+	log := strings.Join([]string{
+		"2020-04-16 11:01:49,123 INFO Connected to 10.0.1.15:9092",
+		"2020-04-16 11:01:50,123 INFO Connected to 10.0.1.16:9092",
+		"2020-04-16 11:01:51,123 WARN Leader not available for partition events-1",
+		"2020-04-16 11:01:52,123 WARN Leader not available for partition orders-3",
+		"2020-04-16 11:01:53,123 INFO Connected to 10.0.1.17:9092",
+	}, "\n")
+	m := NewTemplateMiner(DefaultSimilarity)
+	if err := m.AddFrom(strings.NewReader(log), "task"); err != nil {
+		t.Fatalf("AddFrom() error = %v", err)
+	}
+	type summary struct {
+		Template string
+		Count    int
+		First    time.Time
+		Last     time.Time
+	}
+	got := make([]summary, 0)
+	for _, tmpl := range m.Templates() {
+		got = append(got, summary{tmpl.Template, tmpl.Count, tmpl.First, tmpl.Last})
+	}
+	want := []summary{
+		{
+			"INFO Connected to <IP>", 3,
+			time.Date(2020, 4, 16, 11, 1, 49, 123000000, time.UTC),
+			time.Date(2020, 4, 16, 11, 1, 53, 123000000, time.UTC),
+		},
+		{
+			"WARN Leader not available for partition <*>", 2,
+			time.Date(2020, 4, 16, 11, 1, 51, 123000000, time.UTC),
+			time.Date(2020, 4, 16, 11, 1, 52, 123000000, time.UTC),
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Templates() = %v, want %v", got, want)
+	}
+}
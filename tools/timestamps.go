@@ -0,0 +1,82 @@
+package tools
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 2020-04-16 11:01:49,123, 2020-04-16T11:01:49.123Z, [2020-04-16 11:01:49.123+0000]
+var lineTimeRegexp = regexp.MustCompile(
+	`(\d{4}-\d{2}-\d{2})[T ](\d{2}:\d{2}:\d{2})(?:[.,](\d{1,9}))?(Z|[+-]\d{2}:?\d{2})?`)
+
+// Only the beginning of a line is searched for a timestamp, so that timestamps mentioned in messages are ignored.
+const lineTimeSearchLimit = 64
+
+// ParseLineTime extracts the timestamp of the log line. It returns the timestamp, the line without the timestamp
+// and true if the line starts with a timestamp. Timestamps without a time zone are considered to be in UTC.
+func ParseLineTime(line string) (time.Time, string, bool) {
+	head := line
+	if len(head) > lineTimeSearchLimit {
+		head = head[:lineTimeSearchLimit]
+	}
+	loc := lineTimeRegexp.FindStringSubmatchIndex(head)
+	if loc == nil {
+		return time.Time{}, line, false
+	}
+	groups := make([]string, 5)
+	for i := range groups {
+		if loc[2*i] >= 0 {
+			groups[i] = head[loc[2*i]:loc[2*i+1]]
+		}
+	}
+	value := groups[1] + "T" + groups[2]
+	layout := "2006-01-02T15:04:05"
+	if groups[3] != "" {
+		value += "." + groups[3]
+		layout += "." + strings.Repeat("0", len(groups[3]))
+	}
+	switch {
+	case groups[4] == "Z":
+		value += "Z"
+		layout += "Z07:00"
+	case strings.Contains(groups[4], ":"):
+		value += groups[4]
+		layout += "-07:00"
+	case groups[4] != "":
+		value += groups[4]
+		layout += "-0700"
+	}
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, line, false
+	}
+	start, end := loc[0], loc[1]
+	if start > 0 && end < len(line) && line[start-1] == '[' && line[end] == ']' {
+		start, end = start-1, end+1
+	}
+	return t.UTC(), strings.TrimSpace(line[:start] + line[end:]), true
+}
+
+// ParseTimeFlag parses the time given by a user in a command line flag.
+// It accepts RFC 3339 timestamps, timestamps without a time zone, dates and
+// the format used in the task directory names.
+func ParseTimeFlag(value string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339Nano,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+		"2006-01-02",
+		"20060102T150405",
+	}
+	var err error
+	for _, layout := range layouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, err
+}
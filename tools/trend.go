@@ -0,0 +1,147 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// BundleTrend is the task statistics of a bundle in a trend.
+type BundleTrend struct {
+	Bundle string `json:"bundle"`
+	// CollectedAt is the latest task timestamp, the closest approximation of the collection time
+	// which is found in every bundle.
+	CollectedAt time.Time      `json:"collectedAt"`
+	Tasks       int            `json:"tasks"`
+	States      map[string]int `json:"states"`
+	// Restarts is the number of tasks which are not the first run of the task name.
+	Restarts    int     `json:"restarts"`
+	RestartRate float64 `json:"restartRate"`
+}
+
+// SignatureTrend is the number of lines of an error signature in every bundle of a trend.
+type SignatureTrend struct {
+	Template string `json:"template"`
+	// Counts are in the order of the bundles of the trend.
+	Counts []int `json:"counts"`
+	Total  int   `json:"total"`
+}
+
+// Trend shows how a service changes across bundles.
+type Trend struct {
+	Bundles    []BundleTrend    `json:"bundles"`
+	Signatures []SignatureTrend `json:"signatures"`
+}
+
+// BuildTrend collects the task statistics and error signatures of the bundles and orders the bundles by
+// the collection time. Error signatures of all the bundles are mined together, so that the same error has
+// the same signature in every bundle. Only top signatures by the total number of lines are kept, if top is 0,
+// all of them are kept.
+func BuildTrend(bundlePaths []string, top int) (Trend, error) {
+	type bundle struct {
+		trend BundleTrend
+		tasks []Task
+	}
+	bundles := make([]bundle, 0, len(bundlePaths))
+	for _, path := range bundlePaths {
+		tasks, err := FindTasks(path)
+		if err != nil {
+			return Trend{}, fmt.Errorf("cannot find tasks of the bundle %v: %v", path, err)
+		}
+		b := bundle{trend: BundleTrend{Bundle: path, Tasks: len(tasks), States: make(map[string]int)}, tasks: tasks}
+		for _, t := range tasks {
+			b.trend.States[t.State()]++
+			for _, ts := range []time.Time{t.Staring, t.Running, t.Killed, t.Failed} {
+				if ts.After(b.trend.CollectedAt) {
+					b.trend.CollectedAt = ts
+				}
+			}
+		}
+		b.trend.Restarts = len(restartedTasks(tasks))
+		if len(tasks) != 0 {
+			b.trend.RestartRate = float64(b.trend.Restarts) / float64(len(tasks))
+		}
+		bundles = append(bundles, b)
+	}
+	sort.SliceStable(bundles, func(i, j int) bool {
+		return bundles[i].trend.CollectedAt.Before(bundles[j].trend.CollectedAt)
+	})
+	groups := make([][]Task, 0, len(bundles))
+	for _, b := range bundles {
+		groups = append(groups, b.tasks)
+	}
+	signatures, err := MineErrorSignatureGroups(groups)
+	if err != nil {
+		return Trend{}, fmt.Errorf("cannot mine error signatures: %v", err)
+	}
+	trend := Trend{Bundles: make([]BundleTrend, 0, len(bundles)), Signatures: make([]SignatureTrend, 0)}
+	byTemplate := make(map[string]*SignatureTrend)
+	order := make([]string, 0)
+	for i, b := range bundles {
+		trend.Bundles = append(trend.Bundles, b.trend)
+		for _, s := range signatures[i] {
+			st, ok := byTemplate[s.Template]
+			if !ok {
+				st = &SignatureTrend{Template: s.Template, Counts: make([]int, len(bundles))}
+				byTemplate[s.Template] = st
+				order = append(order, s.Template)
+			}
+			st.Counts[i] += s.Count
+			st.Total += s.Count
+		}
+	}
+	for _, template := range order {
+		trend.Signatures = append(trend.Signatures, *byTemplate[template])
+	}
+	sort.SliceStable(trend.Signatures, func(i, j int) bool {
+		return trend.Signatures[i].Total > trend.Signatures[j].Total
+	})
+	if top > 0 && len(trend.Signatures) > top {
+		trend.Signatures = trend.Signatures[:top]
+	}
+	return trend, nil
+}
+
+// WriteTrend prints the trend in the text or json format. In the text format, bundles are numbered and
+// the error signature table has a column per bundle.
+func WriteTrend(w io.Writer, trend Trend, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(trend)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "#\tCOLLECTED\tTASKS\tSTARTING\tRUNNING\tKILLED\tFAILED\tRESTARTS\tRESTART RATE\tBUNDLE")
+	for i, b := range trend.Bundles {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.2f\t%v\n", i+1, printTime(b.CollectedAt),
+			b.Tasks, b.States[StateStarting], b.States[StateRunning], b.States[StateKilled], b.States[StateFailed],
+			b.Restarts, b.RestartRate, b.Bundle)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(trend.Signatures) == 0 {
+		_, _ = fmt.Fprintln(w, "\nNo error signatures found.")
+		return nil
+	}
+	_, _ = fmt.Fprintln(w, "\nError signature lines by bundle:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i := range trend.Bundles {
+		_, _ = fmt.Fprintf(tw, "%v\t", i+1)
+	}
+	_, _ = fmt.Fprintln(tw, "TOTAL\tSIGNATURE")
+	for _, s := range trend.Signatures {
+		for _, n := range s.Counts {
+			_, _ = fmt.Fprintf(tw, "%v\t", n)
+		}
+		_, _ = fmt.Fprintf(tw, "%v\t%v\n", s.Total, s.Template)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildTrend(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-trend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	orders := "ERROR Cannot reach the leader of the topic orders\n"
+	events := "ERROR Cannot reach the leader of the topic events\n"
+	disk := "FATAL Disk is full\n"
+	// The later bundle is passed first.
+	later, earlier := filepath.Join(dir, "later"), filepath.Join(dir, "earlier")
+	writeTestFiles(t, later, map[string][]byte{
+		"tasks/starting_20200417T110000-failed_20200417T110500__kafka-0-broker__kafka-0-broker__a/stdout":  []byte(events + disk),
+		"tasks/starting_20200417T111000-failed_20200417T111500__kafka-0-broker__kafka-0-broker__b/stdout":  []byte(events),
+		"tasks/starting_20200417T112000-running_20200417T112100__kafka-0-broker__kafka-0-broker__c/stdout": []byte(events),
+		"tasks/starting_20200417T110000-running_20200417T110100__kafka-1-broker__kafka-1-broker__d/stdout": []byte("INFO ok\n"),
+	})
+	writeTestFiles(t, earlier, map[string][]byte{
+		"tasks/starting_20200416T110000-running_20200416T110100__kafka-0-broker__kafka-0-broker__a/stdout": []byte(orders + events),
+		"tasks/starting_20200416T110000-running_20200416T110100__kafka-1-broker__kafka-1-broker__d/stdout": []byte("INFO ok\n"),
+	})
+	trend, err := BuildTrend([]string{later, earlier}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Trend{
+		Bundles: []BundleTrend{
+			{Bundle: earlier, CollectedAt: time.Date(2020, 4, 16, 11, 1, 0, 0, time.UTC), Tasks: 2,
+				States: map[string]int{StateRunning: 2}},
+			{Bundle: later, CollectedAt: time.Date(2020, 4, 17, 11, 21, 0, 0, time.UTC), Tasks: 4,
+				States: map[string]int{StateRunning: 2, StateFailed: 2}, Restarts: 2, RestartRate: 0.5},
+		},
+		Signatures: []SignatureTrend{
+			{Template: "ERROR Cannot reach the leader of the topic <*>", Counts: []int{2, 3}, Total: 5},
+			{Template: "FATAL Disk is full", Counts: []int{0, 1}, Total: 1},
+		},
+	}
+	if !reflect.DeepEqual(trend, want) {
+		t.Errorf("BuildTrend() = %+v, want %+v", trend, want)
+	}
+	var b bytes.Buffer
+	if err := WriteTrend(&b, trend, "text"); err != nil {
+		t.Fatal(err)
+	}
+	rows := make([]string, 0)
+	for _, line := range strings.Split(b.String(), "\n") {
+		rows = append(rows, strings.Join(strings.Fields(line), " "))
+	}
+	for _, want := range []string{
+		"1 " + printTime(want.Bundles[0].CollectedAt) + " 2 0 2 0 0 0 0.00 " + earlier,
+		"2 " + printTime(want.Bundles[1].CollectedAt) + " 4 0 2 0 2 2 0.50 " + later,
+		"2 3 5 ERROR Cannot reach the leader of the topic <*>",
+		"0 1 1 FATAL Disk is full",
+	} {
+		if !containsName(rows, want) {
+			t.Errorf("WriteTrend() = %v, want a row %q", b.String(), want)
+		}
+	}
+}
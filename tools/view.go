@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ViewMarkerFileName is the name of the view manifest file which marks directories created by BuildView.
+// BuildView removes an existing view directory only if it has this file.
+const ViewMarkerFileName = ".sbun-view"
+
+// Link modes define how the task directories are put into a view.
+const (
+	// LinkSymlink creates a symbolic link to the task directory.
+	LinkSymlink = "symlink"
+	// LinkHardlink recreates the task directory with hard links to the task files. Files on other devices
+	// are copied.
+	LinkHardlink = "hardlink"
+	// LinkCopy copies the task directory.
+	LinkCopy = "copy"
+)
+
+// ViewManifest describes what was put into a view. It is stored in the view marker file.
+type ViewManifest struct {
+	Bundle  string      `json:"bundle"`
+	Mode    string      `json:"mode"`
+	GroupBy string      `json:"groupBy,omitempty"`
+	Entries []ViewEntry `json:"entries"`
+}
+
+// ViewEntry is a task in a view.
+type ViewEntry struct {
+	// Path relative to the view directory.
+	Path    string `json:"path"`
+	TaskDir string `json:"taskDir"`
+	// Target of the symbolic link.
+	Target string `json:"target,omitempty"`
+	// HardLinks and Copies are the number of files linked and copied in the hardlink and copy modes.
+	HardLinks int `json:"hardLinks,omitempty"`
+	Copies    int `json:"copies,omitempty"`
+	// Skipped are the task files which were not put into the view in the hardlink and copy modes,
+	// e.g., symbolic links to directories, with the reasons.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// ViewOptions define how BuildView lays out the view.
+type ViewOptions struct {
+	// GroupBy is a task field, see TaskFieldNames. Tasks are put into subdirectories named after
+	// the field value. If it is empty, all the links are put into the view directory.
+	GroupBy string
+	// RawNames makes the links named after the task directories instead of readable names.
+	RawNames bool
+	// Mode is one of LinkSymlink, LinkHardlink or LinkCopy. Default: LinkSymlink.
+	Mode string
+}
+
+// BuildView creates the viewDir directory with a link to the directory of each task and the manifest.
+// Symbolic links are relative if the view is inside the bundle directory, so the bundle can be moved.
+// An existing viewDir is replaced only if it was created by BuildView.
+func BuildView(bundlePath string, viewDir string, tasks []Task, opts ViewOptions) error {
+	if opts.Mode == "" {
+		opts.Mode = LinkSymlink
+	}
+	if opts.Mode != LinkSymlink && opts.Mode != LinkHardlink && opts.Mode != LinkCopy {
+		return fmt.Errorf("unknown link mode %q, known modes: %v, %v, %v",
+			opts.Mode, LinkSymlink, LinkHardlink, LinkCopy)
+	}
+	if opts.GroupBy != "" {
+		if _, ok := taskFields[opts.GroupBy]; !ok {
+			return fmt.Errorf("unknown grouping key %q, known keys: %v",
+				opts.GroupBy, strings.Join(TaskFieldNames(), ", "))
+		}
+	}
+	if err := removeView(viewDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(viewDir, 0777); err != nil {
+		return fmt.Errorf("cannot create directory: %v", err)
+	}
+	absBundlePath, err := filepath.Abs(bundlePath)
+	if err != nil {
+		return err
+	}
+	manifest := ViewManifest{Bundle: absBundlePath, Mode: opts.Mode, GroupBy: opts.GroupBy}
+	// The manifest is written first, so that the view can be removed even if linking fails.
+	if err := writeViewManifest(viewDir, manifest); err != nil {
+		return err
+	}
+	relative := isInside(viewDir, bundlePath)
+	errs := make([]string, 0)
+	for _, task := range tasks {
+		linkDir := viewDir
+		if opts.GroupBy != "" {
+			linkDir = filepath.Join(viewDir, safeFileName(taskFields[opts.GroupBy](task)))
+			if err := os.MkdirAll(linkDir, 0777); err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+		}
+		name := task.DirName
+		if !opts.RawNames {
+			name = readableTaskName(task)
+		}
+		entry := ViewEntry{TaskDir: task.DirName}
+		entry.Path, _ = filepath.Rel(viewDir, filepath.Join(linkDir, name))
+		var err error
+		if opts.Mode == LinkSymlink {
+			entry.Target, err = symlinkTask(task, filepath.Join(linkDir, name), relative)
+		} else {
+			entry.HardLinks, entry.Copies, entry.Skipped, err = copyTree(task.DirNameAbsolute,
+				filepath.Join(linkDir, name), opts.Mode == LinkHardlink)
+		}
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+		manifest.Entries = append(manifest.Entries, entry)
+	}
+	if err := writeViewManifest(viewDir, manifest); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("errors when building view: %v", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func writeViewManifest(viewDir string, manifest ViewManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(viewDir, ViewMarkerFileName), data, 0666); err != nil {
+		return fmt.Errorf("cannot write view manifest: %v", err)
+	}
+	return nil
+}
+
+func symlinkTask(task Task, linkPath string, relative bool) (string, error) {
+	target := task.DirNameAbsolute
+	if relative {
+		absLinkDir, err1 := filepath.Abs(filepath.Dir(linkPath))
+		absTarget, err2 := filepath.Abs(target)
+		if err1 == nil && err2 == nil {
+			if rel, err := filepath.Rel(absLinkDir, absTarget); err == nil {
+				target = rel
+			}
+		}
+	}
+	if err := os.Symlink(target, linkPath); err != nil {
+		return target, fmt.Errorf("cannot create link: %v", err)
+	}
+	return target, nil
+}
+
+// copyTree recreates the src directory in dst, hard linking or copying the files. If a hard link
+// cannot be created, e.g., because dst is on another device, the file is copied. Symbolic links to files
+// are replaced with their targets, because the view can be archived or put on a filesystem without symbolic
+// links; other symbolic links are skipped. It returns the number of hard linked and copied files
+// and the skipped files with the reasons.
+func copyTree(src string, dst string, hardLink bool) (int, int, []string, error) {
+	hardLinks, copies := 0, 0
+	var skipped []string
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.Mode()&os.ModeSymlink != 0 {
+			resolved, err := filepath.EvalSymlinks(path)
+			if err == nil {
+				info, err = os.Stat(resolved)
+			}
+			switch {
+			case err != nil:
+				skipped = append(skipped, fmt.Sprintf("%v: broken symbolic link: %v", rel, err))
+				return nil
+			case !info.Mode().IsRegular():
+				skipped = append(skipped, fmt.Sprintf("%v: symbolic link to a directory or a special file", rel))
+				return nil
+			}
+			path = resolved
+		}
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0777)
+		case !info.Mode().IsRegular():
+			return nil
+		}
+		if hardLink {
+			if err := os.Link(path, target); err == nil {
+				hardLinks++
+				return nil
+			}
+		}
+		if err := copyFile(path, target); err != nil {
+			return err
+		}
+		copies++
+		return nil
+	})
+	if err != nil {
+		return hardLinks, copies, skipped, fmt.Errorf("cannot copy %v: %v", src, err)
+	}
+	return hardLinks, copies, skipped, nil
+}
+
+// TasksWithLogsDirName is the name of the view created by the tasks-with-logs command.
+const TasksWithLogsDirName = "tasks_with_logs"
+
+// removeView removes the directory if it was created by BuildView. Older SBun versions didn't write
+// the marker file, so a tasks_with_logs directory with only symbolic links is removed too.
+func removeView(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot read directory %v: %v", dir, err)
+	}
+	created := false
+	if filepath.Base(dir) == TasksWithLogsDirName && len(infos) != 0 {
+		created = true
+		for _, info := range infos {
+			if info.Mode()&os.ModeSymlink == 0 {
+				created = false
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, ViewMarkerFileName)); err == nil {
+		created = true
+	}
+	if !created {
+		return fmt.Errorf("refusing to remove directory %v, it was not created by SBun", dir)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("cannot remove directory: %v", err)
+	}
+	return nil
+}
+
+// readableTaskName returns a name like kafka-2-broker_20200416T110149_killed_06e119a6.
+func readableTaskName(t Task) string {
+	return safeFileName(strings.Join([]string{t.Name, formatTaskTime(t.Started()), t.State(), shortID(t.ID)}, "_"))
+}
+
+// safeFileName replaces characters which are not allowed in file names on some systems.
+func safeFileName(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// isInside reports whether the path is inside the dir.
+func isInside(path string, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
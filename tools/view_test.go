@@ -0,0 +1,171 @@
+package tools
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	bundle := filepath.Join(dir, "bundle")
+	task, err := parseTaskDirName("starting_20200416T110149-failed_20200416T114052__kafka-0-broker__kafka-0-broker__06e1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.DirNameAbsolute = filepath.Join(bundle, DirNameTasks, task.DirName)
+	writeTestFiles(t, task.DirNameAbsolute, map[string][]byte{
+		"stdout":          []byte("log\n"),
+		"task/stderr":     []byte("error\n"),
+		"kafka/server.sh": []byte("#!/bin/sh\n"),
+	})
+	script := filepath.Join(task.DirNameAbsolute, "kafka", "server.sh")
+	if err := os.Chmod(script, 0755); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2020, 4, 16, 11, 1, 49, 0, time.UTC)
+	if err := os.Chtimes(script, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"stdout.link": "stdout", "kafka/logs": "../task", "broken": "missing"} {
+		if err := os.Symlink(target, filepath.Join(task.DirNameAbsolute, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := readableTaskName(task)
+	for _, mode := range []string{LinkSymlink, LinkHardlink, LinkCopy} {
+		t.Run(mode, func(t *testing.T) {
+			viewDir := filepath.Join(dir, "view-"+mode)
+			if err := BuildView(bundle, viewDir, []Task{task}, ViewOptions{Mode: mode}); err != nil {
+				t.Fatal(err)
+			}
+			data, err := ioutil.ReadFile(filepath.Join(viewDir, ViewMarkerFileName))
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifest := ViewManifest{}
+			if err := json.Unmarshal(data, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			want := ViewEntry{Path: name, TaskDir: task.DirName}
+			switch mode {
+			case LinkSymlink:
+				want.Target = task.DirNameAbsolute
+			case LinkHardlink:
+				want.HardLinks = 4
+			case LinkCopy:
+				want.Copies = 4
+			}
+			if mode != LinkSymlink {
+				want.Skipped = []string{
+					"broken: broken symbolic link: lstat " + filepath.Join(task.DirNameAbsolute, "missing") +
+						": no such file or directory",
+					"kafka/logs: symbolic link to a directory or a special file",
+				}
+			}
+			if manifest.Mode != mode || !reflect.DeepEqual(manifest.Entries, []ViewEntry{want}) {
+				t.Errorf("BuildView() manifest = %+v, want mode %v and entries %+v", manifest, mode, want)
+			}
+			taskView := filepath.Join(viewDir, name)
+			info, err := os.Lstat(taskView)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink != (mode == LinkSymlink) {
+				t.Errorf("task view is a symbolic link: %v, want %v", isLink, mode == LinkSymlink)
+			}
+			if mode == LinkSymlink {
+				return
+			}
+			for path, content := range map[string]string{"stdout": "log\n", "stdout.link": "log\n",
+				"task/stderr": "error\n", "kafka/server.sh": "#!/bin/sh\n"} {
+				info, err := os.Lstat(filepath.Join(taskView, path))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !info.Mode().IsRegular() {
+					t.Errorf("%v is not a regular file", path)
+				}
+				data, err := ioutil.ReadFile(filepath.Join(taskView, path))
+				if err != nil || string(data) != content {
+					t.Errorf("%v = %q, %v, want %q", path, data, err, content)
+				}
+			}
+			info, err = os.Stat(filepath.Join(taskView, "kafka", "server.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0755 || !info.ModTime().Equal(mtime) {
+				t.Errorf("server.sh mode %v, modified %v, want %v, %v", info.Mode().Perm(), info.ModTime(),
+					os.FileMode(0755), mtime)
+			}
+		})
+	}
+}
+
+func Test_removeView(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sbun-view")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	target := filepath.Join(dir, "target")
+	writeTestFiles(t, dir, map[string][]byte{
+		"target":                     []byte("data\n"),
+		"view/" + ViewMarkerFileName: []byte("{}"),
+		"view/file":                  []byte("data\n"),
+		"files/file":                 []byte("data\n"),
+	})
+	for _, link := range []string{"links/a", TasksWithLogsDirName + "/a"} {
+		path := filepath.Join(dir, filepath.FromSlash(link))
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(target, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "empty"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		dir     string
+		removed bool
+	}{
+		{"removes a view with the marker", "view", true},
+		{"removes a legacy tasks_with_logs view", TasksWithLogsDirName, true},
+		{"ignores a missing directory", "missing", false},
+		{"refuses an empty directory", "empty", false},
+		{"refuses a directory with only symbolic links", "links", false},
+		{"refuses a directory with files", "files", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.dir)
+			_, statErr := os.Stat(path)
+			err := removeView(path)
+			if tt.removed || os.IsNotExist(statErr) {
+				if err != nil {
+					t.Fatalf("removeView() error = %v", err)
+				}
+			} else if err == nil {
+				t.Fatalf("removeView() removed %v", tt.dir)
+			}
+			if _, err := os.Lstat(path); os.IsNotExist(err) != (tt.removed || os.IsNotExist(statErr)) {
+				t.Errorf("removeView() left %v: %v", tt.dir, err == nil)
+			}
+		})
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("removeView() removed the link target: %v", err)
+	}
+}